@@ -0,0 +1,549 @@
+package openapispecconverter
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// BundleMode sets how references to other files are bundled into a document.
+type BundleMode int
+
+const (
+	// BundleNone leaves references to other files as they are.
+	BundleNone BundleMode = iota
+	// BundleComponents moves schemas from other files into the components of a document,
+	// or definitions for Swagger, and inlines everything else.
+	BundleComponents
+	// BundleInline replaces every reference to another file with the content it references.
+	BundleInline
+)
+
+func (mode BundleMode) String() string {
+	switch mode {
+	case BundleNone:
+		return "none"
+	case BundleComponents:
+		return "components"
+	case BundleInline:
+		return "inline"
+	default:
+		return fmt.Sprintf("BundleMode(%d)", int(mode))
+	}
+}
+
+// ParseBundleMode parses a bundle mode name: none, components, or inline
+func ParseBundleMode(name string) (BundleMode, error) {
+	switch strings.ToLower(name) {
+	case "none":
+		return BundleNone, nil
+	case "components":
+		return BundleComponents, nil
+	case "inline":
+		return BundleInline, nil
+	default:
+		return 0, fmt.Errorf("Invalid bundle mode: %s", name)
+	}
+}
+
+// schemaKeys are keys for values that hold schemas, or lists of schemas.
+var schemaKeys = []string{
+	"additionalItems",
+	"additionalProperties",
+	"allOf",
+	"anyOf",
+	"contains",
+	"else",
+	"if",
+	"items",
+	"not",
+	"oneOf",
+	"prefixItems",
+	"propertyNames",
+	"schema",
+	"then",
+	"unevaluatedItems",
+	"unevaluatedProperties",
+}
+
+// schemaMapKeys are keys for values that map names to schemas.
+var schemaMapKeys = []string{
+	"$defs",
+	"definitions",
+	"dependentSchemas",
+	"patternProperties",
+	"properties",
+	"schemas",
+}
+
+// schemaDataKeys are keys in schemas for values that are data, which can contain `$ref` keys that are not references.
+var schemaDataKeys = []string{"const", "default", "enum", "example", "examples"}
+
+var invalidComponentNameCharacters = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+type bundler struct {
+	fsys     fs.FS
+	mode     BundleMode
+	rootPath string
+	root     *yaml.Node
+	// source is an unmodified copy of the root document, for loading referenced content.
+	source       *yaml.Node
+	files        map[string]*yaml.Node
+	schemas      *yaml.Node
+	schemaPrefix string
+	// hoisted maps file and fragment pairs to names of schemas moved into the document.
+	hoisted map[string]string
+	// inlining holds file and fragment pairs being inlined, for finding cycles.
+	inlining []string
+	// inlined holds content for file and fragment pairs that have been inlined before.
+	inlined map[string]*yaml.Node
+	// dereference sets if local references should be inlined too.
+	dereference bool
+	// keepCycles sets if a `$ref` should be kept where references form a cycle, instead of failing.
+	keepCycles bool
+}
+
+// mappingValue returns the value for a key in a YAML mapping node.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node != nil && node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				return node.Content[i+1]
+			}
+		}
+	}
+
+	return nil
+}
+
+// mappingKeys returns the keys in a YAML mapping node.
+func mappingKeys(node *yaml.Node) []string {
+	var keys []string
+
+	if node != nil && node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keys = append(keys, node.Content[i].Value)
+		}
+	}
+
+	return keys
+}
+
+// setMappingValue sets the value for a key in a YAML mapping node.
+func setMappingValue(node *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content[i+1] = value
+			return
+		}
+	}
+
+	node.Content = append(
+		node.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		value,
+	)
+}
+
+// copyNode creates a deep copy of a YAML node.
+func copyNode(node *yaml.Node) *yaml.Node {
+	if node == nil {
+		return nil
+	}
+
+	newNode := *node
+	newNode.Content = make([]*yaml.Node, len(node.Content))
+
+	for i, child := range node.Content {
+		newNode.Content[i] = copyNode(child)
+	}
+
+	return &newNode
+}
+
+// documentRoot returns the root content of a YAML document node.
+func documentRoot(node *yaml.Node) *yaml.Node {
+	if node != nil && node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		return node.Content[0]
+	}
+
+	return node
+}
+
+// findPointer finds a node in a document with a JSON pointer.
+func findPointer(node *yaml.Node, pointer string) (*yaml.Node, error) {
+	node = documentRoot(node)
+
+	if pointer == "" || pointer == "/" {
+		return node, nil
+	}
+
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+
+		switch node.Kind {
+		case yaml.MappingNode:
+			node = mappingValue(node, token)
+		case yaml.SequenceNode:
+			if index, err := strconv.Atoi(token); err == nil && index >= 0 && index < len(node.Content) {
+				node = node.Content[index]
+			} else {
+				node = nil
+			}
+		default:
+			node = nil
+		}
+
+		if node == nil {
+			return nil, fmt.Errorf("%s not found", pointer)
+		}
+	}
+
+	return node, nil
+}
+
+// splitReference splits a `$ref` into a file path and a JSON pointer.
+func splitReference(ref string) (string, string, error) {
+	filePart, fragment, _ := strings.Cut(ref, "#")
+	fragment, err := url.PathUnescape(fragment)
+
+	if err != nil {
+		return "", "", err
+	}
+
+	filePart, err = url.PathUnescape(filePart)
+
+	if err != nil {
+		return "", "", err
+	}
+
+	return filePart, fragment, nil
+}
+
+func (bundler *bundler) loadFile(filePath string) (*yaml.Node, error) {
+	if filePath == bundler.rootPath {
+		return bundler.source, nil
+	}
+
+	if node, ok := bundler.files[filePath]; ok {
+		return node, nil
+	}
+
+	data, err := fs.ReadFile(bundler.fsys, filePath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var node yaml.Node
+
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+
+	bundler.files[filePath] = &node
+
+	return &node, nil
+}
+
+// schemaName picks a name for a schema moved into the document that doesn't clash with other schemas.
+func (bundler *bundler) schemaName(filePath string, fragment string) string {
+	name := path.Base(fragment)
+
+	if fragment == "" || fragment == "/" {
+		name = strings.TrimSuffix(path.Base(filePath), path.Ext(filePath))
+	}
+
+	name = invalidComponentNameCharacters.ReplaceAllString(name, "_")
+	uniqueName := name
+
+	for i := 2; mappingValue(bundler.schemas, uniqueName) != nil; i++ {
+		uniqueName = name + strconv.Itoa(i)
+	}
+
+	return uniqueName
+}
+
+// schemaMap returns the mapping node schemas are moved into, and creates it if needed.
+func (bundler *bundler) schemaMap() *yaml.Node {
+	if bundler.schemas != nil {
+		return bundler.schemas
+	}
+
+	root := documentRoot(bundler.root)
+	newMapping := func() *yaml.Node {
+		return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	}
+
+	if mappingValue(root, "swagger") != nil {
+		bundler.schemaPrefix = "#/definitions/"
+		bundler.schemas = mappingValue(root, "definitions")
+
+		if bundler.schemas == nil {
+			bundler.schemas = newMapping()
+			setMappingValue(root, "definitions", bundler.schemas)
+		}
+	} else {
+		bundler.schemaPrefix = "#/components/schemas/"
+		components := mappingValue(root, "components")
+
+		if components == nil {
+			components = newMapping()
+			setMappingValue(root, "components", components)
+		}
+
+		bundler.schemas = mappingValue(components, "schemas")
+
+		if bundler.schemas == nil {
+			bundler.schemas = newMapping()
+			setMappingValue(components, "schemas", bundler.schemas)
+		}
+	}
+
+	return bundler.schemas
+}
+
+// resolveReference bundles the content for a single `$ref` node.
+func (bundler *bundler) resolveReference(
+	node *yaml.Node,
+	refNode *yaml.Node,
+	filePath string,
+	inSchema bool,
+) error {
+	filePart, fragment, err := splitReference(refNode.Value)
+
+	if err != nil {
+		return &ReferenceError{Ref: refNode.Value, File: filePath, Err: err}
+	}
+
+	targetPath := filePath
+
+	if filePart != "" {
+		if strings.Contains(filePart, "://") {
+			// Leave remote references alone.
+			return nil
+		}
+
+		targetPath = path.Join(path.Dir(filePath), filePart)
+	}
+
+	if targetPath == bundler.rootPath && !bundler.dereference {
+		// References to the root document become local references.
+		refNode.Value = "#" + fragment
+
+		return nil
+	}
+
+	file, err := bundler.loadFile(targetPath)
+
+	if err != nil {
+		return &ReferenceError{Ref: refNode.Value, File: filePath, Err: err}
+	}
+
+	target, err := findPointer(file, fragment)
+
+	if err != nil {
+		return &ReferenceError{Ref: refNode.Value, File: filePath, Err: err}
+	}
+
+	key := "#" + fragment
+
+	if targetPath != bundler.rootPath {
+		key = targetPath + key
+	}
+
+	if bundler.mode == BundleComponents && inSchema {
+		schemas := bundler.schemaMap()
+		name, ok := bundler.hoisted[key]
+
+		if !ok {
+			name = bundler.schemaName(targetPath, fragment)
+			bundler.hoisted[key] = name
+			schema := copyNode(target)
+			setMappingValue(schemas, name, schema)
+
+			if err := bundler.resolve(schema, targetPath, true); err != nil {
+				return err
+			}
+		}
+
+		refNode.Value = bundler.schemaPrefix + name
+
+		return nil
+	}
+
+	if index := slices.Index(bundler.inlining, key); index >= 0 {
+		if bundler.keepCycles && targetPath == bundler.rootPath {
+			refNode.Value = key
+
+			return nil
+		}
+
+		return &CircularReferenceError{Cycle: append(slices.Clone(bundler.inlining[index:]), key)}
+	}
+
+	content, ok := bundler.inlined[key]
+
+	if !ok {
+		bundler.inlining = append(bundler.inlining, key)
+		content = copyNode(target)
+
+		if err := bundler.resolve(content, targetPath, inSchema); err != nil {
+			return err
+		}
+
+		bundler.inlining = bundler.inlining[:len(bundler.inlining)-1]
+		bundler.inlined[key] = content
+	}
+
+	content = copyNode(content)
+
+	// Keep keys next to the `$ref` that aren't set in the referenced content.
+	siblings := node.Content
+	*node = *content
+
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(siblings); i += 2 {
+			if siblings[i].Value != "$ref" && mappingValue(node, siblings[i].Value) == nil {
+				node.Content = append(node.Content, siblings[i], siblings[i+1])
+			}
+		}
+	}
+
+	return nil
+}
+
+// schemasPointer returns the pointer to a map of component schemas in the root document being dereferenced,
+// or an empty string for any other map of schemas.
+func (bundler *bundler) schemasPointer(node *yaml.Node, filePath string) string {
+	if !bundler.dereference || filePath != bundler.rootPath {
+		return ""
+	}
+
+	root := documentRoot(bundler.root)
+
+	if node == mappingValue(mappingValue(root, "components"), "schemas") {
+		return "/components/schemas"
+	}
+
+	if node == mappingValue(root, "definitions") {
+		return "/definitions"
+	}
+
+	return ""
+}
+
+// resolve bundles references to other files in a node from the file at filePath.
+func (bundler *bundler) resolve(node *yaml.Node, filePath string, inSchema bool) error {
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, child := range node.Content {
+			if err := bundler.resolve(child, filePath, inSchema); err != nil {
+				return err
+			}
+		}
+	case yaml.MappingNode:
+		if refNode := mappingValue(node, "$ref"); refNode != nil && refNode.Kind == yaml.ScalarNode {
+			// Local references in the root document don't need to change, unless we are dereferencing.
+			if bundler.dereference || filePath != bundler.rootPath || !strings.HasPrefix(refNode.Value, "#") {
+				return bundler.resolveReference(node, refNode, filePath, inSchema)
+			}
+
+			return nil
+		}
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+
+			// Skip values that are data and not part of the document structure.
+			if (inSchema && slices.Contains(schemaDataKeys, key)) || key == "example" || key == "value" {
+				continue
+			}
+
+			value := node.Content[i+1]
+
+			// Names in maps of schemas could be any value, so resolve each schema directly.
+			if slices.Contains(schemaMapKeys, key) && value.Kind == yaml.MappingNode {
+				pointer := bundler.schemasPointer(value, filePath)
+
+				for j := 1; j < len(value.Content); j += 2 {
+					// Component schemas are being inlined into themselves, so cycles keep a `$ref` to the schema.
+					if len(pointer) > 0 {
+						bundler.inlining = append(bundler.inlining, "#"+joinPointer(pointer, value.Content[j-1].Value))
+					}
+
+					err := bundler.resolve(value.Content[j], filePath, true)
+
+					if len(pointer) > 0 {
+						bundler.inlining = bundler.inlining[:len(bundler.inlining)-1]
+					}
+
+					if err != nil {
+						return err
+					}
+				}
+
+				continue
+			}
+
+			childInSchema := inSchema || slices.Contains(schemaKeys, key)
+
+			if err := bundler.resolve(value, filePath, childInSchema); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// run resolves references in a document with a bundler, and renders the document again.
+func (bundler *bundler) run(data []byte) ([]byte, error) {
+	var root yaml.Node
+
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, &ParseError{Err: err}
+	}
+
+	bundler.root = &root
+	bundler.source = copyNode(&root)
+	bundler.inlined = map[string]*yaml.Node{}
+	bundler.files = map[string]*yaml.Node{}
+	bundler.hoisted = map[string]string{}
+	bundler.rootPath = path.Clean(bundler.rootPath)
+
+	if err := bundler.resolve(&root, bundler.rootPath, false); err != nil {
+		return nil, err
+	}
+
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(&root); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// bundleDocument resolves references to other files in a document and bundles them into a single document.
+//
+// documentPath is the path of the document in fsys, which relative references are resolved against.
+func bundleDocument(data []byte, fsys fs.FS, documentPath string, mode BundleMode) ([]byte, error) {
+	bundler := bundler{
+		fsys:     fsys,
+		mode:     mode,
+		rootPath: documentPath,
+	}
+
+	return bundler.run(data)
+}
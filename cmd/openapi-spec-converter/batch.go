@@ -0,0 +1,305 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	openapispecconverter "github.com/dense-analysis/openapi-spec-converter"
+)
+
+// batchInput is a single file to convert in batch mode.
+type batchInput struct {
+	path string
+	// relDir is the directory of the file relative to the directory given as input,
+	// so the directory structure can be kept in the output directory.
+	relDir string
+}
+
+// batchResult is the outcome of converting a single file in batch mode.
+type batchResult struct {
+	outputPath string
+	warnings   int
+	// failed is true if the conversion reported a change at least as severe as --fail-on.
+	failed bool
+	err    error
+}
+
+func isDocumentFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json", ".yaml", ".yml":
+		return true
+	default:
+		return false
+	}
+}
+
+// hasNoVersion returns true if a file can be read, but has no top-level `openapi` or `swagger` field,
+// such as JSON Schema files or configuration files next to the documents in a directory.
+func hasNoVersion(path string) bool {
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return false
+	}
+
+	var versionErr *openapispecconverter.UnsupportedVersionError
+	_, err = openapispecconverter.DetectVersion(data)
+
+	return errors.As(err, &versionErr) && len(versionErr.Pointer) == 0
+}
+
+// collectInputs adds a file, or every JSON or YAML file in a directory, to a list of inputs.
+func collectInputs(inputs []batchInput, path string) ([]batchInput, error) {
+	info, err := os.Stat(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		return append(inputs, batchInput{path: path, relDir: "."}), nil
+	}
+
+	err = filepath.WalkDir(path, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !entry.IsDir() && isDocumentFile(filePath) {
+			relPath, err := filepath.Rel(path, filePath)
+
+			if err != nil {
+				return err
+			}
+
+			inputs = append(inputs, batchInput{path: filePath, relDir: filepath.Dir(relPath)})
+		}
+
+		return nil
+	})
+
+	return inputs, err
+}
+
+// collectDocuments adds a file, or every OpenAPI or Swagger document in a directory, to a list of inputs.
+//
+// Other files in a directory are added to the skipped files instead,
+// while files that are named directly are always converted, so they fail if they aren't documents.
+func collectDocuments(inputs []batchInput, skipped []string, path string) ([]batchInput, []string, error) {
+	collected, err := collectInputs(nil, path)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		return append(inputs, collected...), skipped, nil
+	}
+
+	for _, input := range collected {
+		if hasNoVersion(input.path) {
+			skipped = append(skipped, input.path)
+		} else {
+			inputs = append(inputs, input)
+		}
+	}
+
+	return inputs, skipped, nil
+}
+
+// expandInputs expands input files, directories, and glob patterns into a list of files.
+// Patterns that match no files are returned separately, so the other inputs can still be converted,
+// along with files in directories which were skipped.
+func expandInputs(patterns []string) ([]batchInput, []string, []string, error) {
+	var inputs []batchInput
+	var skipped []string
+	var unmatched []string
+	var err error
+
+	for _, pattern := range patterns {
+		count := len(inputs) + len(skipped)
+
+		if _, statErr := os.Stat(pattern); statErr == nil {
+			if inputs, skipped, err = collectDocuments(inputs, skipped, pattern); err != nil {
+				return nil, nil, nil, err
+			}
+		} else {
+			matches, err := filepath.Glob(pattern)
+
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("Invalid pattern %s: %w", pattern, err)
+			}
+
+			for _, match := range matches {
+				if inputs, skipped, err = collectDocuments(inputs, skipped, match); err != nil {
+					return nil, nil, nil, err
+				}
+			}
+		}
+
+		if len(inputs)+len(skipped) == count {
+			unmatched = append(unmatched, pattern)
+		}
+	}
+
+	return inputs, skipped, unmatched, nil
+}
+
+// batchOutputPath returns the output path for an input from the filename template.
+func batchOutputPath(arguments Arguments, input batchInput) string {
+	name := filepath.Base(input.path)
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+
+	filename := strings.NewReplacer(
+		"{name}", name,
+		"{target}", arguments.outputTarget.String(),
+		"{format}", arguments.outputFormat.String(),
+	).Replace(arguments.nameTemplate)
+
+	return filepath.Join(arguments.outDir, input.relDir, filename)
+}
+
+func convertBatchFile(arguments Arguments, input batchInput, outputPath string) batchResult {
+	result := batchResult{outputPath: outputPath}
+	data, err := os.ReadFile(input.path)
+
+	if err != nil {
+		result.err = fmt.Errorf("Error reading input file: %w", err)
+
+		return result
+	}
+
+	report := &openapispecconverter.Report{}
+	converter := openapispecconverter.NewConverter(converterOptions(arguments, report, input.path)...)
+
+	if data, err = converter.Convert(data); err != nil {
+		result.err = err
+
+		return result
+	}
+
+	if err = os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		result.err = fmt.Errorf("Error creating output directory: %w", err)
+
+		return result
+	}
+
+	if err = os.WriteFile(outputPath, data, 0644); err != nil {
+		result.err = fmt.Errorf("Error writing output file: %w", err)
+
+		return result
+	}
+
+	for _, diagnostic := range report.Diagnostics {
+		if diagnostic.Severity >= openapispecconverter.SeverityWarning {
+			result.warnings++
+		}
+	}
+
+	result.failed = arguments.failOn != nil && report.HasSeverity(*arguments.failOn)
+
+	return result
+}
+
+// convertBatch converts every input into the output directory, prints a summary, and returns the exit status.
+//
+// The status is 1 if any file could not be converted,
+// and 2 if every file was converted, but some reported changes at least as severe as --fail-on.
+func convertBatch(arguments Arguments) int {
+	inputs, skipped, unmatched, err := expandInputs(arguments.inputFilenames)
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	for _, pattern := range unmatched {
+		fmt.Printf("failed %s: No files match\n", pattern)
+	}
+
+	for _, path := range skipped {
+		fmt.Printf("skip   %s: Not an OpenAPI or Swagger document\n", path)
+	}
+
+	results := make([]batchResult, len(inputs))
+	outputInputs := map[string]string{}
+
+	for i, input := range inputs {
+		results[i].outputPath = batchOutputPath(arguments, input)
+
+		// Don't let two inputs overwrite the same output file.
+		if otherPath, ok := outputInputs[results[i].outputPath]; ok {
+			results[i].err = fmt.Errorf("Output file %s is already written for %s", results[i].outputPath, otherPath)
+		} else {
+			outputInputs[results[i].outputPath] = input.path
+		}
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+
+	for range min(arguments.jobs, len(inputs)) {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range indexes {
+				results[i] = convertBatchFile(arguments, inputs[i], results[i].outputPath)
+			}
+		}()
+	}
+
+	for i := range inputs {
+		if results[i].err == nil {
+			indexes <- i
+		}
+	}
+
+	close(indexes)
+	wg.Wait()
+
+	converted := 0
+	failed := 0
+
+	for i, result := range results {
+		if result.err != nil {
+			fmt.Printf("failed %s: %v\n", inputs[i].path, result.err)
+		} else {
+			converted++
+
+			if result.failed {
+				failed++
+			}
+
+			switch result.warnings {
+			case 0:
+				fmt.Printf("ok     %s -> %s\n", inputs[i].path, result.outputPath)
+			case 1:
+				fmt.Printf("ok     %s -> %s (1 warning)\n", inputs[i].path, result.outputPath)
+			default:
+				fmt.Printf("ok     %s -> %s (%d warnings)\n", inputs[i].path, result.outputPath, result.warnings)
+			}
+		}
+	}
+
+	fmt.Printf("Converted %d of %d files\n", converted, len(inputs))
+
+	if failed > 0 {
+		fmt.Printf("%d files reported changes at least as severe as %s\n", failed, *arguments.failOn)
+	}
+
+	switch {
+	case converted == 0, converted < len(inputs), len(unmatched) > 0:
+		return 1
+	case failed > 0:
+		return 2
+	default:
+		return 0
+	}
+}
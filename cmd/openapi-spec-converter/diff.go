@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	openapispecconverter "github.com/dense-analysis/openapi-spec-converter"
+	"github.com/pborman/getopt/v2"
+)
+
+type DiffArguments struct {
+	oldFilename  string
+	newFilename  string
+	outputFormat string
+}
+
+func parseDiffArgs(args []string) DiffArguments {
+	var arguments DiffArguments
+
+	set := getopt.New()
+	set.SetProgram(filepath.Base(os.Args[0]) + " diff")
+	set.SetParameters("<old> <new>")
+
+	showHelp := set.BoolLong("help", 'h', "Print this help message")
+	outputFormat := set.StringLong("format", 'f', "text", "Output format: text or json")
+
+	set.Parse(args)
+
+	if *showHelp {
+		set.PrintUsage(os.Stdout)
+		os.Exit(0)
+	}
+
+	if len(set.Args()) != 2 {
+		fmt.Fprintln(os.Stderr, "Invalid number of arguments")
+		set.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	switch strings.ToLower(*outputFormat) {
+	case "text", "json":
+		arguments.outputFormat = strings.ToLower(*outputFormat)
+	default:
+		fmt.Fprintf(os.Stderr, "Invalid format: %s\n", *outputFormat)
+		set.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	arguments.oldFilename = set.Args()[0]
+	arguments.newFilename = set.Args()[1]
+
+	return arguments
+}
+
+func readFileOrStdin(filename string) ([]byte, error) {
+	if filename == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+
+	return os.ReadFile(filename)
+}
+
+// diff prints the changes between two documents, and exits with status 1 for breaking changes.
+func diff(args []string) {
+	arguments := parseDiffArgs(args)
+
+	oldData, err := readFileOrStdin(arguments.oldFilename)
+
+	if err != nil {
+		log.Fatalf("Error reading old file %v\n", err)
+	}
+
+	newData, err := readFileOrStdin(arguments.newFilename)
+
+	if err != nil {
+		log.Fatalf("Error reading new file %v\n", err)
+	}
+
+	result, err := openapispecconverter.Diff(oldData, newData)
+
+	if err != nil {
+		log.Fatalf("Error comparing documents: %v\n", err)
+	}
+
+	if arguments.outputFormat == "json" {
+		data, err := json.MarshalIndent(result, "", "  ")
+
+		if err != nil {
+			log.Fatalf("Error rendering changes: %v\n", err)
+		}
+
+		fmt.Println(string(data))
+	} else {
+		breaking := 0
+
+		for _, change := range result.Changes {
+			if change.Breaking {
+				breaking++
+				fmt.Printf("breaking %s: %s\n", change.Pointer, change.Message)
+			} else {
+				fmt.Printf("         %s: %s\n", change.Pointer, change.Message)
+			}
+		}
+
+		fmt.Printf("%d changes, %d breaking\n", len(result.Changes), breaking)
+	}
+
+	if result.HasBreakingChanges() {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	openapispecconverter "github.com/dense-analysis/openapi-spec-converter"
+	"github.com/pborman/getopt/v2"
+)
+
+type ExamplesArguments struct {
+	inputFilename  string
+	outputFilename string
+	outDir         string
+	reportFilename string
+	outputTarget   openapispecconverter.SpecVersion
+	outputFormat   openapispecconverter.Format
+}
+
+func parseExamplesArgs(args []string) ExamplesArguments {
+	var arguments ExamplesArguments
+	var err error
+
+	set := getopt.New()
+	set.SetProgram(filepath.Base(os.Args[0]) + " examples")
+	set.SetParameters("<input>")
+
+	showHelp := set.BoolLong("help", 'h', "Print this help message")
+	outputFilename := set.StringLong("output", 'o', "", "Output file for the document with examples added (default stdout)")
+	outDir := set.StringLong("out-dir", 0, "", "Write each example to its own JSON file in this directory")
+	outputVersion := set.StringLong("target", 't', "3.1", "Target version: swagger, 3.0, 3.1, 3.2, postman, or an exact version such as 3.0.3")
+	outputFormat := set.StringLong("format", 'f', "json", "Output format: yaml or json")
+	reportFilename := set.StringLong("report", 0, "", "Write a report of changes made during conversion to a file")
+
+	set.Parse(args)
+
+	if *showHelp {
+		set.PrintUsage(os.Stdout)
+		os.Exit(0)
+	}
+
+	if len(set.Args()) != 1 {
+		fmt.Fprintln(os.Stderr, "Invalid number of arguments")
+		set.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	if arguments.outputTarget, err = openapispecconverter.ParseSpecVersion(*outputVersion); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		set.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	if arguments.outputFormat, err = openapispecconverter.ParseFormat(*outputFormat); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		set.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	if len(*outputFilename) > 0 && len(*outDir) > 0 {
+		fmt.Fprintln(os.Stderr, "--output and --out-dir cannot be used together")
+		set.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	arguments.inputFilename = set.Args()[0]
+	arguments.outputFilename = *outputFilename
+	arguments.outDir = *outDir
+	arguments.reportFilename = *reportFilename
+
+	return arguments
+}
+
+// generateExamples writes example request and response bodies for a document,
+// or writes the document with the examples added to it.
+func generateExamples(args []string) {
+	arguments := parseExamplesArgs(args)
+
+	data, err := readFileOrStdin(arguments.inputFilename)
+
+	if err != nil {
+		log.Fatalf("Error reading input file %v\n", err)
+	}
+
+	report := &openapispecconverter.Report{}
+	var files map[string][]byte
+
+	if len(arguments.outDir) > 0 {
+		if files, err = openapispecconverter.GenerateExamples(data, report); err != nil {
+			log.Fatalf("Error generating examples: %+v\n", err)
+		}
+	} else {
+		if data, err = openapispecconverter.AddExamples(data, report); err != nil {
+			log.Fatalf("Error generating examples: %+v\n", err)
+		}
+
+		converter := openapispecconverter.NewConverter(
+			openapispecconverter.WithTarget(arguments.outputTarget),
+			openapispecconverter.WithFormat(arguments.outputFormat),
+			openapispecconverter.WithReport(report),
+		)
+
+		if data, err = converter.Convert(data); err != nil {
+			log.Fatalf("Error converting document: %+v\n", err)
+		}
+	}
+
+	if len(arguments.reportFilename) > 0 {
+		var reportData []byte
+
+		if reportData, err = json.MarshalIndent(report, "", "  "); err == nil {
+			err = os.WriteFile(arguments.reportFilename, reportData, 0644)
+		}
+
+		if err != nil {
+			log.Fatalf("Error writing report file: %v\n", err)
+		}
+	}
+
+	if len(arguments.outDir) > 0 {
+		if err = os.MkdirAll(arguments.outDir, 0755); err != nil {
+			log.Fatalf("Error creating output directory: %v\n", err)
+		}
+
+		for _, filename := range slices.Sorted(maps.Keys(files)) {
+			if err = os.WriteFile(filepath.Join(arguments.outDir, filename), files[filename], 0644); err != nil {
+				log.Fatalf("Error writing output file: %v\n", err)
+			}
+
+			fmt.Println(filepath.Join(arguments.outDir, filename))
+		}
+	} else if len(arguments.outputFilename) > 0 {
+		if err = os.WriteFile(arguments.outputFilename, data, 0644); err != nil {
+			log.Fatalf("Error writing output file: %v\n", err)
+		}
+	} else {
+		fmt.Println(string(data))
+	}
+}
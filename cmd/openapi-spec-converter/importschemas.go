@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	openapispecconverter "github.com/dense-analysis/openapi-spec-converter"
+	"github.com/pborman/getopt/v2"
+)
+
+type ImportSchemasArguments struct {
+	inputPath        string
+	documentFilename string
+	outputFilename   string
+	reportFilename   string
+	outputTarget     openapispecconverter.SpecVersion
+	outputFormat     openapispecconverter.Format
+}
+
+func parseImportSchemasArgs(args []string) ImportSchemasArguments {
+	var arguments ImportSchemasArguments
+	var err error
+
+	set := getopt.New()
+	set.SetProgram(filepath.Base(os.Args[0]) + " import-schemas")
+	set.SetParameters("<directory>")
+
+	showHelp := set.BoolLong("help", 'h', "Print this help message")
+	documentFilename := set.StringLong("into", 'i', "", "Add the schemas to this document instead of a new document")
+	outputFilename := set.StringLong("output", 'o', "", "Output file (default stdout)")
+	outputVersion := set.StringLong("target", 't', "3.1", "Target version: swagger, 3.0, 3.1, 3.2, postman, or an exact version such as 3.0.3")
+	outputFormat := set.StringLong("format", 'f', "json", "Output format: yaml or json")
+	reportFilename := set.StringLong("report", 0, "", "Write a report of changes made during conversion to a file")
+
+	set.Parse(args)
+
+	if *showHelp {
+		set.PrintUsage(os.Stdout)
+		os.Exit(0)
+	}
+
+	if len(set.Args()) != 1 {
+		fmt.Fprintln(os.Stderr, "Invalid number of arguments")
+		set.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	if arguments.outputTarget, err = openapispecconverter.ParseSpecVersion(*outputVersion); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		set.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	if arguments.outputFormat, err = openapispecconverter.ParseFormat(*outputFormat); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		set.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	arguments.inputPath = set.Args()[0]
+	arguments.documentFilename = *documentFilename
+	arguments.outputFilename = *outputFilename
+	arguments.reportFilename = *reportFilename
+
+	return arguments
+}
+
+// readSchemaFiles reads every JSON or YAML file in a directory by slash separated path.
+func readSchemaFiles(path string) (map[string][]byte, error) {
+	inputs, err := collectInputs(nil, path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string][]byte{}
+
+	for _, input := range inputs {
+		data, err := os.ReadFile(input.path)
+
+		if err != nil {
+			return nil, err
+		}
+
+		files[filepath.ToSlash(filepath.Join(input.relDir, filepath.Base(input.path)))] = data
+	}
+
+	return files, nil
+}
+
+// importSchemas writes a document with the JSON Schema files in a directory as component schemas.
+func importSchemas(args []string) {
+	arguments := parseImportSchemasArgs(args)
+
+	files, err := readSchemaFiles(arguments.inputPath)
+
+	if err != nil {
+		log.Fatalf("Error reading schema files %v\n", err)
+	}
+
+	var document []byte
+
+	if len(arguments.documentFilename) > 0 {
+		if document, err = readFileOrStdin(arguments.documentFilename); err != nil {
+			log.Fatalf("Error reading document file %v\n", err)
+		}
+	}
+
+	report := &openapispecconverter.Report{}
+	data, err := openapispecconverter.ImportSchemas(files, document, report)
+
+	if err != nil {
+		log.Fatalf("Error importing schemas: %+v\n", err)
+	}
+
+	converter := openapispecconverter.NewConverter(
+		openapispecconverter.WithTarget(arguments.outputTarget),
+		openapispecconverter.WithFormat(arguments.outputFormat),
+		openapispecconverter.WithReport(report),
+	)
+
+	if data, err = converter.Convert(data); err != nil {
+		log.Fatalf("Error converting document: %+v\n", err)
+	}
+
+	if len(arguments.reportFilename) > 0 {
+		var reportData []byte
+
+		if reportData, err = json.MarshalIndent(report, "", "  "); err == nil {
+			err = os.WriteFile(arguments.reportFilename, reportData, 0644)
+		}
+
+		if err != nil {
+			log.Fatalf("Error writing report file: %v\n", err)
+		}
+	}
+
+	if len(arguments.outputFilename) > 0 {
+		if err = os.WriteFile(arguments.outputFilename, data, 0644); err != nil {
+			log.Fatalf("Error writing output file: %v\n", err)
+		}
+	} else {
+		fmt.Println(string(data))
+	}
+}
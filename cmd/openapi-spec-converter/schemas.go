@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	openapispecconverter "github.com/dense-analysis/openapi-spec-converter"
+	"github.com/pborman/getopt/v2"
+)
+
+type SchemasArguments struct {
+	inputFilename  string
+	outputFilename string
+	outDir         string
+	reportFilename string
+	draft          openapispecconverter.SchemaDraft
+}
+
+func parseSchemasArgs(args []string) SchemasArguments {
+	var arguments SchemasArguments
+	var err error
+
+	set := getopt.New()
+	set.SetProgram(filepath.Base(os.Args[0]) + " schemas")
+	set.SetParameters("<input>")
+
+	showHelp := set.BoolLong("help", 'h', "Print this help message")
+	outputFilename := set.StringLong("output", 'o', "", "Output file for every schema in $defs (default stdout)")
+	outDir := set.StringLong("out-dir", 0, "", "Write each schema to its own file in this directory")
+	draft := set.StringLong("draft", 's', "2020-12", "JSON Schema draft: 2020-12 or draft-07")
+	reportFilename := set.StringLong("report", 0, "", "Write a report of changes made during conversion to a file")
+
+	set.Parse(args)
+
+	if *showHelp {
+		set.PrintUsage(os.Stdout)
+		os.Exit(0)
+	}
+
+	if len(set.Args()) != 1 {
+		fmt.Fprintln(os.Stderr, "Invalid number of arguments")
+		set.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	if arguments.draft, err = openapispecconverter.ParseSchemaDraft(*draft); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		set.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	if len(*outputFilename) > 0 && len(*outDir) > 0 {
+		fmt.Fprintln(os.Stderr, "--output and --out-dir cannot be used together")
+		set.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	arguments.inputFilename = set.Args()[0]
+	arguments.outputFilename = *outputFilename
+	arguments.outDir = *outDir
+	arguments.reportFilename = *reportFilename
+
+	return arguments
+}
+
+// exportSchemas writes the component schemas in a document as JSON Schema.
+func exportSchemas(args []string) {
+	arguments := parseSchemasArgs(args)
+
+	data, err := readFileOrStdin(arguments.inputFilename)
+
+	if err != nil {
+		log.Fatalf("Error reading input file %v\n", err)
+	}
+
+	mode := openapispecconverter.SchemaExportBundle
+
+	if len(arguments.outDir) > 0 {
+		mode = openapispecconverter.SchemaExportFiles
+	}
+
+	report := &openapispecconverter.Report{}
+	files, err := openapispecconverter.ExportSchemas(data, arguments.draft, mode, report)
+
+	if err != nil {
+		log.Fatalf("Error exporting schemas: %+v\n", err)
+	}
+
+	if len(arguments.reportFilename) > 0 {
+		if data, err = json.MarshalIndent(report, "", "  "); err == nil {
+			err = os.WriteFile(arguments.reportFilename, data, 0644)
+		}
+
+		if err != nil {
+			log.Fatalf("Error writing report file: %v\n", err)
+		}
+	}
+
+	if mode == openapispecconverter.SchemaExportFiles {
+		if err = os.MkdirAll(arguments.outDir, 0755); err != nil {
+			log.Fatalf("Error creating output directory: %v\n", err)
+		}
+
+		for _, filename := range slices.Sorted(maps.Keys(files)) {
+			if err = os.WriteFile(filepath.Join(arguments.outDir, filename), files[filename], 0644); err != nil {
+				log.Fatalf("Error writing output file: %v\n", err)
+			}
+
+			fmt.Println(filepath.Join(arguments.outDir, filename))
+		}
+	} else if len(arguments.outputFilename) > 0 {
+		if err = os.WriteFile(arguments.outputFilename, files[openapispecconverter.SchemaBundleFilename], 0644); err != nil {
+			log.Fatalf("Error writing output file: %v\n", err)
+		}
+	} else {
+		fmt.Print(string(files[openapispecconverter.SchemaBundleFilename]))
+	}
+}
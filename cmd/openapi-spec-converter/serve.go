@@ -0,0 +1,294 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	openapispecconverter "github.com/dense-analysis/openapi-spec-converter"
+	"github.com/pborman/getopt/v2"
+)
+
+type ServeArguments struct {
+	addr        string
+	maxBodySize int64
+}
+
+func parseServeArgs(args []string) ServeArguments {
+	var arguments ServeArguments
+
+	set := getopt.New()
+	set.SetProgram(filepath.Base(os.Args[0]) + " serve")
+	set.SetParameters("")
+
+	showHelp := set.BoolLong("help", 'h', "Print this help message")
+	addr := set.StringLong("addr", 'a', ":8080", "Address to listen on")
+	maxBodySize := set.Int64Long("max-body-size", 0, 10<<20, "Maximum size of a request body in bytes")
+
+	set.Parse(args)
+
+	if *showHelp {
+		set.PrintUsage(os.Stdout)
+		os.Exit(0)
+	}
+
+	if len(set.Args()) > 0 {
+		fmt.Fprintln(os.Stderr, "Invalid number of arguments")
+		set.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	if *maxBodySize <= 0 {
+		fmt.Fprintf(os.Stderr, "Invalid maximum body size: %d\n", *maxBodySize)
+		set.PrintUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	arguments.addr = *addr
+	arguments.maxBodySize = *maxBodySize
+
+	return arguments
+}
+
+// errorResponse is the JSON body sent for every failed request.
+type errorResponse struct {
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func writeError(writer http.ResponseWriter, status int, errorType string, message string) {
+	var response errorResponse
+
+	response.Error.Type = errorType
+	response.Error.Message = message
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+	json.NewEncoder(writer).Encode(response)
+}
+
+func writeConversionError(writer http.ResponseWriter, err error) {
+	var parseError *openapispecconverter.ParseError
+	var versionError *openapispecconverter.UnsupportedVersionError
+	var conversionError *openapispecconverter.ConversionError
+	var formatError *openapispecconverter.FormatError
+
+	switch {
+	case errors.As(err, &parseError):
+		writeError(writer, http.StatusBadRequest, "parse", err.Error())
+	case errors.As(err, &versionError):
+		writeError(writer, http.StatusUnprocessableEntity, "unsupported-version", err.Error())
+	case errors.As(err, &conversionError):
+		writeError(writer, http.StatusUnprocessableEntity, "conversion", err.Error())
+	case errors.As(err, &formatError):
+		writeError(writer, http.StatusUnprocessableEntity, "format", err.Error())
+	default:
+		writeError(writer, http.StatusInternalServerError, "internal", err.Error())
+	}
+}
+
+// acceptedFormats returns the formats a media type in an Accept header matches,
+// and true if the media type names a format instead of using a wildcard.
+func acceptedFormats(mediaType string) ([]openapispecconverter.Format, bool) {
+	switch mediaType {
+	case "application/json":
+		return []openapispecconverter.Format{openapispecconverter.JSON}, true
+	case "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml":
+		return []openapispecconverter.Format{openapispecconverter.YAML}, true
+	case "*/*", "application/*":
+		return []openapispecconverter.Format{openapispecconverter.JSON, openapispecconverter.YAML}, false
+	case "text/*":
+		return []openapispecconverter.Format{openapispecconverter.YAML}, false
+	default:
+		return nil, false
+	}
+}
+
+// negotiateFormat picks an output format from an Accept header.
+//
+// The format with the highest quality value is used, where media types naming a format
+// take precedence over wildcards, and a quality of 0 rejects a format.
+// JSON is used if both formats are accepted equally, and false is returned if the
+// client accepts neither JSON nor YAML.
+func negotiateFormat(accept string) (openapispecconverter.Format, bool) {
+	if len(strings.TrimSpace(accept)) == 0 {
+		return openapispecconverter.JSON, true
+	}
+
+	qualities := map[openapispecconverter.Format]float64{}
+	named := map[openapispecconverter.Format]bool{}
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+
+		if value, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(value, 64); err != nil || quality < 0 || quality > 1 {
+				continue
+			}
+		}
+
+		formats, isNamed := acceptedFormats(mediaType)
+
+		for _, format := range formats {
+			switch {
+			case isNamed && !named[format]:
+				named[format] = true
+				qualities[format] = quality
+			case isNamed == named[format]:
+				qualities[format] = max(qualities[format], quality)
+			}
+		}
+	}
+
+	format := openapispecconverter.JSON
+
+	if qualities[openapispecconverter.YAML] > qualities[openapispecconverter.JSON] {
+		format = openapispecconverter.YAML
+	}
+
+	return format, qualities[format] > 0
+}
+
+func convertHandler(maxBodySize int64) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		if request.Method != http.MethodPost {
+			writer.Header().Set("Allow", http.MethodPost)
+			writeError(writer, http.StatusMethodNotAllowed, "method", "Only POST is supported")
+			return
+		}
+
+		query := request.URL.Query()
+		target := openapispecconverter.OpenAPI31
+		format := openapispecconverter.JSON
+		var err error
+
+		if query.Has("target") {
+			if target, err = openapispecconverter.ParseSpecVersion(query.Get("target")); err != nil {
+				writeError(writer, http.StatusBadRequest, "parameter", err.Error())
+				return
+			}
+		}
+
+		if query.Has("format") {
+			if format, err = openapispecconverter.ParseFormat(query.Get("format")); err != nil {
+				writeError(writer, http.StatusBadRequest, "parameter", err.Error())
+				return
+			}
+		} else {
+			var ok bool
+
+			if format, ok = negotiateFormat(request.Header.Get("Accept")); !ok {
+				writeError(
+					writer,
+					http.StatusNotAcceptable,
+					"not-acceptable",
+					"Documents can only be returned as application/json or application/yaml",
+				)
+				return
+			}
+		}
+
+		data, err := io.ReadAll(http.MaxBytesReader(writer, request.Body, maxBodySize))
+
+		if err != nil {
+			var maxBytesError *http.MaxBytesError
+
+			if errors.As(err, &maxBytesError) {
+				writeError(
+					writer,
+					http.StatusRequestEntityTooLarge,
+					"too-large",
+					fmt.Sprintf("Request body is larger than %d bytes", maxBytesError.Limit),
+				)
+			} else {
+				writeError(writer, http.StatusBadRequest, "read", fmt.Sprintf("Error reading request body: %v", err))
+			}
+
+			return
+		}
+
+		converter := openapispecconverter.NewConverter(
+			openapispecconverter.WithTarget(target),
+			openapispecconverter.WithFormat(format),
+		)
+
+		data, err = converter.Convert(data)
+
+		if err != nil {
+			writeConversionError(writer, err)
+			return
+		}
+
+		if format == openapispecconverter.YAML {
+			writer.Header().Set("Content-Type", "application/yaml")
+		} else {
+			writer.Header().Set("Content-Type", "application/json")
+		}
+
+		writer.Write(data)
+	}
+}
+
+func serve(args []string) {
+	arguments := parseServeArgs(args)
+
+	mux := http.NewServeMux()
+	mux.Handle("/convert", convertHandler(arguments.maxBodySize))
+
+	// Limit the time a client can take, so slow requests can't hold up the server.
+	// The size of request bodies is limited by the handler.
+	server := &http.Server{
+		Addr:              arguments.addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      60 * time.Second,
+		IdleTimeout:       120 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	shutdownDone := make(chan struct{})
+
+	go func() {
+		defer close(shutdownDone)
+		<-ctx.Done()
+
+		// Give requests in progress time to finish before exiting.
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Error shutting down server: %v\n", err)
+		}
+	}()
+
+	log.Printf("Listening on %s\n", arguments.addr)
+
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatalf("Error starting server: %v\n", err)
+	}
+
+	<-shutdownDone
+}
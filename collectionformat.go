@@ -0,0 +1,542 @@
+package openapispecconverter
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// collectionFormatExtension keeps a Swagger collectionFormat with no OpenAPI equivalent,
+// so it can be restored when converting back to Swagger.
+const collectionFormatExtension = "x-collectionFormat"
+
+// formMediaTypes are the request body media types Swagger formData parameters are converted to.
+//
+// multipart/form-data is first, as it is the media type converted to formData parameters
+// when a request body has both, because it can send files.
+var formMediaTypes = []string{"multipart/form-data", "application/x-www-form-urlencoded"}
+
+// defaultStyle returns the style and explode values OpenAPI uses for a parameter location
+// when they are not set.
+func defaultStyle(in string) (string, bool) {
+	if in == "path" || in == "header" {
+		return "simple", false
+	}
+
+	return "form", true
+}
+
+// collectionFormatToStyle returns the OpenAPI style and explode values for a Swagger collectionFormat.
+//
+// false is returned if there is no equivalent for the collectionFormat in the parameter location.
+func collectionFormatToStyle(collectionFormat string, in string) (string, bool, bool) {
+	delimited := in == "query" || in == "formData"
+
+	switch collectionFormat {
+	case "", "csv":
+		if in == "path" || in == "header" {
+			return "simple", false, true
+		}
+
+		return "form", false, true
+	case "ssv":
+		return "spaceDelimited", false, delimited
+	case "pipes":
+		return "pipeDelimited", false, delimited
+	case "multi":
+		return "form", true, delimited
+	default:
+		return "", false, false
+	}
+}
+
+// styleToCollectionFormat returns the Swagger collectionFormat for OpenAPI style and explode values.
+//
+// false is returned if Swagger has no equivalent for the style.
+func styleToCollectionFormat(style string, explode *bool, in string) (string, bool) {
+	defaultStyleName, defaultExplode := defaultStyle(in)
+
+	if len(style) == 0 {
+		style = defaultStyleName
+	}
+
+	exploded := style == "form" && defaultExplode
+
+	if explode != nil {
+		exploded = *explode
+	}
+
+	switch style {
+	case "simple":
+		return "csv", true
+	case "form":
+		if exploded {
+			return "multi", true
+		}
+
+		return "csv", true
+	case "spaceDelimited":
+		if exploded {
+			return "multi", true
+		}
+
+		return "ssv", true
+	case "pipeDelimited":
+		if exploded {
+			return "multi", true
+		}
+
+		return "pipes", true
+	default:
+		return "", false
+	}
+}
+
+// convertSwaggerCollectionFormat sets the style and explode values for a Swagger array parameter.
+//
+// Style and explode are only set if they differ from the defaults for the parameter location.
+func convertSwaggerCollectionFormat(
+	pointer string,
+	collectionFormat string,
+	in string,
+	extensions map[string]any,
+	style *string,
+	explode **bool,
+	report *Report,
+) {
+	newStyle, newExplode, ok := collectionFormatToStyle(collectionFormat, in)
+
+	if !ok {
+		// Fall back to the closest style, a comma separated list.
+		if defaultStyleName, _ := defaultStyle(in); defaultStyleName == "form" {
+			*explode = new(bool)
+		}
+
+		extensions[collectionFormatExtension] = collectionFormat
+
+		report.Add(Diagnostic{
+			Pointer:  joinPointer(pointer, "collectionFormat"),
+			Rule:     "collection-format-to-style",
+			Severity: SeverityWarning,
+			Message: fmt.Sprintf(
+				"collectionFormat %s has no equivalent style for %s parameters, kept as %s",
+				collectionFormat,
+				in,
+				collectionFormatExtension,
+			),
+			Before: map[string]any{"collectionFormat": collectionFormat},
+			After:  map[string]any{collectionFormatExtension: collectionFormat},
+		})
+
+		return
+	}
+
+	defaultStyleName, defaultExplode := defaultStyle(in)
+
+	if newStyle == defaultStyleName && newExplode == defaultExplode {
+		return
+	}
+
+	if newStyle != defaultStyleName {
+		*style = newStyle
+	}
+
+	if newExplode != defaultExplode {
+		*explode = &newExplode
+	}
+
+	if len(collectionFormat) == 0 {
+		collectionFormat = "csv"
+	}
+
+	report.Add(Diagnostic{
+		Pointer:  joinPointer(pointer, "collectionFormat"),
+		Rule:     "collection-format-to-style",
+		Severity: SeverityInfo,
+		Message:  fmt.Sprintf("Replaced collectionFormat %s with style %s and explode %v", collectionFormat, newStyle, newExplode),
+		Before:   map[string]any{"collectionFormat": collectionFormat},
+		After:    map[string]any{"style": newStyle, "explode": newExplode},
+	})
+}
+
+func convertSwaggerParameterCollectionFormat(
+	pointer string,
+	swaggerParameter *openapi2.Parameter,
+	parameter *openapi3.Parameter,
+	report *Report,
+) {
+	if swaggerParameter.Type == nil || !swaggerParameter.Type.Is("array") {
+		return
+	}
+
+	if parameter.Extensions == nil {
+		parameter.Extensions = map[string]any{}
+	}
+
+	convertSwaggerCollectionFormat(
+		pointer,
+		swaggerParameter.CollectionFormat,
+		parameter.In,
+		parameter.Extensions,
+		&parameter.Style,
+		&parameter.Explode,
+		report,
+	)
+}
+
+// findParameter finds a parameter that isn't a reference by its location and name.
+func findParameter(parameters openapi3.Parameters, in string, name string) *openapi3.Parameter {
+	for _, parameterRef := range parameters {
+		if parameterRef != nil && parameterRef.Ref == "" && parameterRef.Value != nil &&
+			parameterRef.Value.In == in && parameterRef.Value.Name == name {
+			return parameterRef.Value
+		}
+	}
+
+	return nil
+}
+
+func convertSwaggerParametersCollectionFormats(
+	pointer string,
+	swaggerParameters openapi2.Parameters,
+	parameters openapi3.Parameters,
+	requestBody *openapi3.RequestBodyRef,
+	report *Report,
+) {
+	for i, swaggerParameter := range swaggerParameters {
+		if swaggerParameter == nil || swaggerParameter.Ref != "" {
+			continue
+		}
+
+		parameterPointer := joinPointer(pointer, "parameters", fmt.Sprint(i))
+
+		if swaggerParameter.In == "formData" {
+			if requestBody == nil || requestBody.Ref != "" || requestBody.Value == nil ||
+				swaggerParameter.Type == nil || !swaggerParameter.Type.Is("array") {
+				continue
+			}
+
+			// formData parameters become properties of a form request body,
+			// where style and explode are set with an encoding.
+			// The parameter is only reported once, even if there are several form media types.
+			mediaTypeReport := report
+
+			for _, mediaTypeName := range formMediaTypes {
+				if mediaType := requestBody.Value.Content.Get(mediaTypeName); mediaType != nil {
+					encoding := mediaType.Encoding[swaggerParameter.Name]
+
+					if encoding == nil {
+						encoding = &openapi3.Encoding{}
+					}
+
+					if encoding.Extensions == nil {
+						encoding.Extensions = map[string]any{}
+					}
+
+					convertSwaggerCollectionFormat(
+						parameterPointer,
+						swaggerParameter.CollectionFormat,
+						swaggerParameter.In,
+						encoding.Extensions,
+						&encoding.Style,
+						&encoding.Explode,
+						mediaTypeReport,
+					)
+
+					mediaTypeReport = nil
+
+					if len(encoding.Style) > 0 || encoding.Explode != nil || len(encoding.Extensions) > 0 {
+						if mediaType.Encoding == nil {
+							mediaType.Encoding = map[string]*openapi3.Encoding{}
+						}
+
+						mediaType.Encoding[swaggerParameter.Name] = encoding
+					}
+				}
+			}
+		} else if parameter := findParameter(parameters, swaggerParameter.In, swaggerParameter.Name); parameter != nil {
+			convertSwaggerParameterCollectionFormat(parameterPointer, swaggerParameter, parameter, report)
+		}
+	}
+}
+
+// convertSwaggerCollectionFormats sets style and explode for parameters converted from Swagger,
+// which kin-openapi doesn't do.
+func convertSwaggerCollectionFormats(swaggerDoc *openapi2.T, doc *openapi3.T, report *Report) {
+	for _, name := range slices.Sorted(maps.Keys(swaggerDoc.Parameters)) {
+		swaggerParameter := swaggerDoc.Parameters[name]
+
+		if swaggerParameter == nil || swaggerParameter.Ref != "" || doc.Components == nil {
+			continue
+		}
+
+		if parameterRef := doc.Components.Parameters[name]; parameterRef != nil && parameterRef.Value != nil {
+			convertSwaggerParameterCollectionFormat(
+				joinPointer("", "parameters", name),
+				swaggerParameter,
+				parameterRef.Value,
+				report,
+			)
+		}
+	}
+
+	for _, path := range slices.Sorted(maps.Keys(swaggerDoc.Paths)) {
+		swaggerPathItem := swaggerDoc.Paths[path]
+		pathItem := doc.Paths.Value(path)
+
+		if swaggerPathItem == nil || pathItem == nil {
+			continue
+		}
+
+		pathPointer := joinPointer("", "paths", path)
+		convertSwaggerParametersCollectionFormats(pathPointer, swaggerPathItem.Parameters, pathItem.Parameters, nil, report)
+
+		operations := pathItem.Operations()
+		swaggerOperations := swaggerPathItem.Operations()
+
+		for _, method := range slices.Sorted(maps.Keys(swaggerOperations)) {
+			if operation := operations[method]; operation != nil {
+				convertSwaggerParametersCollectionFormats(
+					joinPointer(pathPointer, strings.ToLower(method)),
+					swaggerOperations[method].Parameters,
+					operation.Parameters,
+					operation.RequestBody,
+					report,
+				)
+			}
+		}
+	}
+}
+
+// convertStyleToCollectionFormat returns the Swagger collectionFormat for a converted parameter.
+//
+// An empty string is returned for the default csv collectionFormat.
+func convertStyleToCollectionFormat(
+	pointer string,
+	style string,
+	explode *bool,
+	in string,
+	extensions map[string]any,
+	isArray bool,
+	report *Report,
+) string {
+	// Restore collectionFormat values that have no OpenAPI style.
+	if collectionFormat, ok := extensions[collectionFormatExtension].(string); ok {
+		delete(extensions, collectionFormatExtension)
+
+		return collectionFormat
+	}
+
+	collectionFormat, ok := styleToCollectionFormat(style, explode, in)
+
+	if !ok {
+		report.Add(Diagnostic{
+			Pointer:  joinPointer(pointer, "style"),
+			Rule:     "style-to-collection-format",
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("Removed style %s, which has no Swagger equivalent", style),
+			Before:   map[string]any{"style": style},
+		})
+
+		return ""
+	}
+
+	if !isArray {
+		return ""
+	}
+
+	if collectionFormat == "multi" && in != "query" && in != "formData" {
+		report.Add(Diagnostic{
+			Pointer:  joinPointer(pointer, "explode"),
+			Rule:     "style-to-collection-format",
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("Removed explode for a %s parameter, which Swagger only supports in query and formData", in),
+			Before:   map[string]any{"style": style, "explode": true},
+		})
+
+		return ""
+	}
+
+	if len(style) > 0 || explode != nil {
+		report.Add(Diagnostic{
+			Pointer:  joinPointer(pointer, "style"),
+			Rule:     "style-to-collection-format",
+			Severity: SeverityInfo,
+			Message:  fmt.Sprintf("Replaced style and explode with collectionFormat %s", collectionFormat),
+			Before:   map[string]any{"style": style, "explode": explode},
+			After:    map[string]any{"collectionFormat": collectionFormat},
+		})
+	}
+
+	if collectionFormat == "csv" {
+		return ""
+	}
+
+	return collectionFormat
+}
+
+func convertParameterStyle(
+	pointer string,
+	parameter *openapi3.Parameter,
+	swaggerParameter *openapi2.Parameter,
+	report *Report,
+) {
+	isArray := parameter.Schema != nil && parameter.Schema.Value != nil && parameter.Schema.Value.Type.Is("array")
+	extensions := swaggerParameter.Extensions
+
+	if extensions == nil {
+		extensions = map[string]any{}
+	}
+
+	swaggerParameter.CollectionFormat = convertStyleToCollectionFormat(
+		pointer,
+		parameter.Style,
+		parameter.Explode,
+		parameter.In,
+		extensions,
+		isArray,
+		report,
+	)
+
+	if len(extensions) == 0 {
+		extensions = nil
+	}
+
+	swaggerParameter.Extensions = extensions
+}
+
+// findSwaggerParameter finds a parameter that isn't a reference by its location and name.
+func findSwaggerParameter(parameters openapi2.Parameters, in string, name string) *openapi2.Parameter {
+	for _, parameter := range parameters {
+		if parameter != nil && parameter.Ref == "" && parameter.In == in && parameter.Name == name {
+			return parameter
+		}
+	}
+
+	return nil
+}
+
+func convertParametersStyles(
+	pointer string,
+	parameters openapi3.Parameters,
+	requestBody *openapi3.RequestBodyRef,
+	swaggerParameters openapi2.Parameters,
+	report *Report,
+) {
+	for i, parameterRef := range parameters {
+		if parameterRef == nil || parameterRef.Ref != "" || parameterRef.Value == nil {
+			continue
+		}
+
+		parameter := parameterRef.Value
+
+		if swaggerParameter := findSwaggerParameter(swaggerParameters, parameter.In, parameter.Name); swaggerParameter != nil {
+			convertParameterStyle(joinPointer(pointer, "parameters", fmt.Sprint(i)), parameter, swaggerParameter, report)
+		}
+	}
+
+	if requestBody == nil || requestBody.Ref != "" || requestBody.Value == nil {
+		return
+	}
+
+	for _, mediaTypeName := range formMediaTypes {
+		mediaType := requestBody.Value.Content.Get(mediaTypeName)
+
+		if mediaType == nil {
+			continue
+		}
+
+		// Properties of a form request body become formData parameters,
+		// where style and explode are set with an encoding.
+		for _, swaggerParameter := range swaggerParameters {
+			if swaggerParameter == nil || swaggerParameter.In != "formData" {
+				continue
+			}
+
+			isArray := swaggerParameter.Type != nil && swaggerParameter.Type.Is("array")
+			encoding := mediaType.Encoding[swaggerParameter.Name]
+			style := ""
+			var explode *bool
+			extensions := map[string]any{}
+
+			if encoding != nil {
+				style = encoding.Style
+				explode = encoding.Explode
+
+				if encoding.Extensions != nil {
+					extensions = encoding.Extensions
+				}
+			}
+
+			swaggerParameter.CollectionFormat = convertStyleToCollectionFormat(
+				joinPointer(pointer, "requestBody", "content", mediaTypeName, "encoding", swaggerParameter.Name),
+				style,
+				explode,
+				"formData",
+				extensions,
+				isArray,
+				report,
+			)
+		}
+
+		// Only one form media type is converted to formData parameters.
+		break
+	}
+}
+
+// convertStylesToCollectionFormats sets collectionFormat for parameters converted to Swagger,
+// which kin-openapi doesn't do.
+func convertStylesToCollectionFormats(doc *openapi3.T, swaggerDoc *openapi2.T, report *Report) {
+	if doc.Components != nil {
+		for _, name := range slices.Sorted(maps.Keys(doc.Components.Parameters)) {
+			parameterRef := doc.Components.Parameters[name]
+			swaggerParameter := swaggerDoc.Parameters[name]
+
+			if parameterRef != nil && parameterRef.Ref == "" && parameterRef.Value != nil && swaggerParameter != nil {
+				convertParameterStyle(
+					joinPointer("", "components", "parameters", name),
+					parameterRef.Value,
+					swaggerParameter,
+					report,
+				)
+			}
+		}
+	}
+
+	if doc.Paths == nil {
+		return
+	}
+
+	paths := doc.Paths.Map()
+
+	for _, path := range slices.Sorted(maps.Keys(paths)) {
+		pathItem := paths[path]
+		swaggerPathItem := swaggerDoc.Paths[path]
+
+		if pathItem == nil || swaggerPathItem == nil {
+			continue
+		}
+
+		pathPointer := joinPointer("", "paths", path)
+		convertParametersStyles(pathPointer, pathItem.Parameters, nil, swaggerPathItem.Parameters, report)
+
+		operations := pathItem.Operations()
+		swaggerOperations := swaggerPathItem.Operations()
+
+		for _, method := range slices.Sorted(maps.Keys(operations)) {
+			if swaggerOperation := swaggerOperations[method]; swaggerOperation != nil {
+				convertParametersStyles(
+					joinPointer(pathPointer, strings.ToLower(method)),
+					operations[method].Parameters,
+					operations[method].RequestBody,
+					swaggerOperation.Parameters,
+					report,
+				)
+			}
+		}
+	}
+}
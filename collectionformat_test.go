@@ -0,0 +1,162 @@
+package openapispecconverter
+
+import (
+	"testing"
+)
+
+func TestCollectionFormatToStyle(t *testing.T) {
+	tests := []struct {
+		collectionFormat string
+		in               string
+		style            string
+		explode          bool
+		ok               bool
+	}{
+		{"", "query", "form", false, true},
+		{"csv", "query", "form", false, true},
+		{"csv", "formData", "form", false, true},
+		{"csv", "path", "simple", false, true},
+		{"csv", "header", "simple", false, true},
+		{"ssv", "query", "spaceDelimited", false, true},
+		{"ssv", "formData", "spaceDelimited", false, true},
+		{"ssv", "path", "spaceDelimited", false, false},
+		{"ssv", "header", "spaceDelimited", false, false},
+		{"pipes", "query", "pipeDelimited", false, true},
+		{"pipes", "formData", "pipeDelimited", false, true},
+		{"pipes", "path", "pipeDelimited", false, false},
+		{"pipes", "header", "pipeDelimited", false, false},
+		{"multi", "query", "form", true, true},
+		{"multi", "formData", "form", true, true},
+		{"multi", "path", "form", true, false},
+		{"multi", "header", "form", true, false},
+		{"tsv", "query", "", false, false},
+		{"tsv", "formData", "", false, false},
+		{"tsv", "path", "", false, false},
+		{"tsv", "header", "", false, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.collectionFormat+"/"+test.in, func(t *testing.T) {
+			style, explode, ok := collectionFormatToStyle(test.collectionFormat, test.in)
+
+			if style != test.style || explode != test.explode || ok != test.ok {
+				t.Errorf(
+					"Expected (%q, %v, %v), got (%q, %v, %v)",
+					test.style, test.explode, test.ok,
+					style, explode, ok,
+				)
+			}
+		})
+	}
+}
+
+func TestStyleToCollectionFormat(t *testing.T) {
+	exploded := true
+	notExploded := false
+
+	tests := []struct {
+		name             string
+		style            string
+		explode          *bool
+		in               string
+		collectionFormat string
+		ok               bool
+	}{
+		{"default query", "", nil, "query", "multi", true},
+		{"default formData", "", nil, "formData", "multi", true},
+		{"default path", "", nil, "path", "csv", true},
+		{"default header", "", nil, "header", "csv", true},
+		{"form query", "form", nil, "query", "multi", true},
+		{"form query not exploded", "form", &notExploded, "query", "csv", true},
+		{"form query exploded", "form", &exploded, "query", "multi", true},
+		{"simple path", "simple", nil, "path", "csv", true},
+		{"simple header exploded", "simple", &exploded, "header", "csv", true},
+		{"spaceDelimited query", "spaceDelimited", nil, "query", "ssv", true},
+		{"spaceDelimited query exploded", "spaceDelimited", &exploded, "query", "multi", true},
+		{"pipeDelimited query", "pipeDelimited", nil, "query", "pipes", true},
+		{"pipeDelimited formData", "pipeDelimited", &notExploded, "formData", "pipes", true},
+		{"pipeDelimited query exploded", "pipeDelimited", &exploded, "query", "multi", true},
+		{"matrix path", "matrix", nil, "path", "", false},
+		{"label path", "label", nil, "path", "", false},
+		{"deepObject query", "deepObject", &exploded, "query", "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			collectionFormat, ok := styleToCollectionFormat(test.style, test.explode, test.in)
+
+			if collectionFormat != test.collectionFormat || ok != test.ok {
+				t.Errorf("Expected (%q, %v), got (%q, %v)", test.collectionFormat, test.ok, collectionFormat, ok)
+			}
+		})
+	}
+}
+
+func TestCollectionFormatRoundTrip(t *testing.T) {
+	for _, in := range []string{"query", "formData", "path", "header"} {
+		for _, collectionFormat := range []string{"csv", "ssv", "pipes", "multi"} {
+			style, explode, ok := collectionFormatToStyle(collectionFormat, in)
+
+			if !ok {
+				continue
+			}
+
+			if result, ok := styleToCollectionFormat(style, &explode, in); !ok || result != collectionFormat {
+				t.Errorf("Expected %s for %s parameters to convert back to %s, got %q", collectionFormat, in, collectionFormat, result)
+			}
+		}
+	}
+}
+
+func TestFormCollectionFormatReportedOnce(t *testing.T) {
+	data := []byte(`swagger: "2.0"
+info:
+  title: Forms
+  version: 1.0.0
+consumes:
+  - application/x-www-form-urlencoded
+  - multipart/form-data
+paths:
+  /items:
+    post:
+      parameters:
+        - name: tags
+          in: formData
+          type: array
+          collectionFormat: tsv
+          items:
+            type: string
+        - name: ids
+          in: formData
+          type: array
+          collectionFormat: pipes
+          items:
+            type: integer
+      responses:
+        "200":
+          description: OK
+`)
+	report := &Report{}
+	converter := NewConverter(WithTarget(OpenAPI30), WithFormat(YAML), WithReport(report))
+
+	if _, err := converter.Convert(data); err != nil {
+		t.Fatal(err)
+	}
+
+	counts := map[string]int{}
+
+	for _, diagnostic := range report.Diagnostics {
+		if diagnostic.Rule == "collection-format-to-style" {
+			counts[diagnostic.Pointer]++
+		}
+	}
+
+	for _, pointer := range []string{
+		"/paths/~1items/post/parameters/0/collectionFormat",
+		"/paths/~1items/post/parameters/1/collectionFormat",
+	} {
+		if counts[pointer] != 1 {
+			t.Errorf("Expected 1 diagnostic for %s, got %d", pointer, counts[pointer])
+		}
+	}
+}
@@ -18,6 +18,49 @@ if ! node_modules/.bin/swagger-cli validate output/31-spec-with-differences-from
     exit_code=1
 fi
 
+echo 'Converting 3.1 spec to 3.0 with x-webhooks'
+docker run --rm -i openapi-spec-converter:latest -t 3.0 -f yaml --x-webhooks \
+    < specs/31-spec-with-differences-from-30.yaml \
+    > output/31-spec-with-differences-from-30.converted-30-x-webhooks.yaml
+
+echo 'Validating 3.1 spec converted to 3.0 with x-webhooks'
+if ! node_modules/.bin/swagger-cli validate output/31-spec-with-differences-from-30.converted-30-x-webhooks.yaml; then
+    exit_code=1
+fi
+
+echo 'Converting 3.1 spec to exactly 3.0.3'
+docker run --rm -i openapi-spec-converter:latest -t 3.0.3 -f yaml \
+    < specs/31-spec-with-differences-from-30.yaml \
+    > output/31-spec-with-differences-from-30.converted-303.yaml
+
+echo 'Validating 3.1 spec converted to exactly 3.0.3'
+if ! node_modules/.bin/swagger-cli validate output/31-spec-with-differences-from-30.converted-303.yaml; then
+    exit_code=1
+fi
+
+echo 'Checking the spec converted to exactly 3.0.3 has that version'
+if ! grep -q '^openapi: "\?3\.0\.3"\?$' output/31-spec-with-differences-from-30.converted-303.yaml; then
+    echo 'The openapi version was not set to 3.0.3'
+    exit_code=1
+fi
+
+echo 'Checking a spec with no version is rejected without --input-version'
+if docker run --rm -i openapi-spec-converter:latest -t 3.1 -f yaml \
+    < specs/30-missing-version.yaml > /dev/null 2>&1; then
+    echo 'A spec with no version was converted without --input-version'
+    exit_code=1
+fi
+
+echo 'Converting spec with no version to 3.1 as 3.0'
+docker run --rm -i openapi-spec-converter:latest -t 3.1 -f yaml --input-version 3.0 \
+    < specs/30-missing-version.yaml \
+    > output/30-missing-version.converted-31.yaml
+
+echo 'Validating spec with no version converted to 3.1 as 3.0'
+if ! node_modules/.bin/redocly lint output/30-missing-version.converted-31.yaml 2>&1; then
+    exit_code=1
+fi
+
 echo 'Converting 3.1 spec to Swagger'
 docker run --rm -i openapi-spec-converter:latest -t swagger -f yaml \
     < specs/31-spec-with-differences-from-30.yaml \
@@ -39,4 +82,335 @@ if ! node_modules/.bin/redocly lint output/31-spec-with-differences-from-30.back
     exit_code=1
 fi
 
+echo 'Converting Swagger collectionFormat spec to 3.0'
+docker run --rm -i openapi-spec-converter:latest -t 3.0 -f yaml \
+    < specs/swagger-collection-formats.yaml \
+    > output/swagger-collection-formats.converted-30.yaml
+
+echo 'Validating Swagger collectionFormat spec converted to 3.0'
+if ! node_modules/.bin/swagger-cli validate output/swagger-collection-formats.converted-30.yaml; then
+    exit_code=1
+fi
+
+echo 'Converting Swagger collectionFormat spec back to Swagger again'
+docker run --rm -i openapi-spec-converter:latest -t swagger -f yaml \
+    < output/swagger-collection-formats.converted-30.yaml \
+    > output/swagger-collection-formats.back-to-swagger.yaml
+
+echo 'Validating Swagger collectionFormat spec converted back from 3.0'
+if ! node_modules/.bin/swagger-cli validate output/swagger-collection-formats.back-to-swagger.yaml; then
+    exit_code=1
+fi
+
+echo 'Converting Swagger nullable spec to 3.1'
+docker run --rm -i openapi-spec-converter:latest -t 3.1 -f yaml \
+    < specs/swagger-nullable.yaml \
+    > output/swagger-nullable.converted-31.yaml
+
+echo 'Converting Swagger nullable spec back to Swagger again'
+docker run --rm -i openapi-spec-converter:latest -t swagger -f yaml \
+    < output/swagger-nullable.converted-31.yaml \
+    > output/swagger-nullable.back-to-swagger.yaml
+
+echo 'Validating Swagger nullable spec converted back from 3.1'
+if ! node_modules/.bin/swagger-cli validate output/swagger-nullable.back-to-swagger.yaml; then
+    exit_code=1
+fi
+
+echo 'Converting Swagger formData spec to 3.0'
+docker run --rm -i openapi-spec-converter:latest -t 3.0 -f yaml \
+    < specs/swagger-form-data.yaml \
+    > output/swagger-form-data.converted-30.yaml
+
+echo 'Validating Swagger formData spec converted to 3.0'
+if ! node_modules/.bin/swagger-cli validate output/swagger-form-data.converted-30.yaml; then
+    exit_code=1
+fi
+
+echo 'Converting Swagger formData spec to 3.1 and back to Swagger again'
+docker run --rm -i openapi-spec-converter:latest -t 3.1 -f yaml \
+    < specs/swagger-form-data.yaml \
+    | docker run --rm -i openapi-spec-converter:latest -t swagger -f yaml \
+    > output/swagger-form-data.back-to-swagger.yaml
+
+echo 'Validating Swagger formData spec converted back from 3.1'
+if ! node_modules/.bin/swagger-cli validate output/swagger-form-data.back-to-swagger.yaml; then
+    exit_code=1
+fi
+
+echo 'Converting 3.0 servers spec to Swagger'
+docker run --rm -i openapi-spec-converter:latest -t swagger -f yaml \
+    < specs/30-servers.yaml \
+    > output/30-servers.converted-swagger.yaml
+
+echo 'Validating 3.0 servers spec converted to Swagger'
+if ! node_modules/.bin/swagger-cli validate output/30-servers.converted-swagger.yaml; then
+    exit_code=1
+fi
+
+echo 'Converting 3.0 security spec to Swagger'
+docker run --rm -i openapi-spec-converter:latest -t swagger -f yaml \
+    < specs/30-security.yaml \
+    > output/30-security.converted-swagger.yaml
+
+echo 'Validating 3.0 security spec converted to Swagger'
+if ! node_modules/.bin/swagger-cli validate output/30-security.converted-swagger.yaml; then
+    exit_code=1
+fi
+
+echo 'Converting 3.0 discriminator spec to Swagger'
+docker run --rm -i openapi-spec-converter:latest -t swagger -f yaml \
+    < specs/30-discriminators.yaml \
+    > output/30-discriminators.converted-swagger.yaml
+
+echo 'Validating 3.0 discriminator spec converted to Swagger'
+if ! node_modules/.bin/swagger-cli validate output/30-discriminators.converted-swagger.yaml; then
+    exit_code=1
+fi
+
+echo 'Converting 3.0 discriminator spec converted to Swagger back to 3.0'
+docker run --rm -i openapi-spec-converter:latest -t 3.0 -f yaml \
+    < output/30-discriminators.converted-swagger.yaml \
+    > output/30-discriminators.converted-30.yaml
+
+echo 'Validating 3.0 discriminator spec converted back to 3.0'
+if ! node_modules/.bin/swagger-cli validate output/30-discriminators.converted-30.yaml; then
+    exit_code=1
+fi
+
+echo 'Converting 3.0 formats spec to 3.1'
+docker run --rm -i openapi-spec-converter:latest -t 3.1 -f yaml --fail-on warning \
+    < specs/30-formats.yaml \
+    > output/30-formats.converted-31.yaml
+
+echo 'Validating 3.0 formats spec converted to 3.1'
+if ! node_modules/.bin/redocly lint output/30-formats.converted-31.yaml 2>&1; then
+    exit_code=1
+fi
+
+echo 'Converting 3.0 formats spec converted to 3.1 back to 3.0'
+docker run --rm -i openapi-spec-converter:latest -t 3.0 -f yaml \
+    < output/30-formats.converted-31.yaml \
+    > output/30-formats.converted-30.yaml
+
+echo 'Checking formats in parameters, headers, and encodings are kept'
+if [ "$(grep -c 'format:' specs/30-formats.yaml)" != "$(grep -c 'format:' output/30-formats.converted-30.yaml)" ]; then
+    echo 'Formats were lost converting 3.0 to 3.1 and back'
+    exit_code=1
+fi
+
+echo 'Converting multi-file spec to Swagger with every $ref inlined'
+docker run --rm -v "$PWD/specs/multi-file:/multi-file:ro" openapi-spec-converter:latest \
+    -t swagger -f yaml --dereference /multi-file/openapi.yaml \
+    > output/multi-file.dereferenced-swagger.yaml
+
+echo 'Validating multi-file spec converted to Swagger with every $ref inlined'
+if ! node_modules/.bin/swagger-cli validate output/multi-file.dereferenced-swagger.yaml; then
+    exit_code=1
+fi
+
+echo 'Checking only the recursive schema keeps a $ref'
+if grep '\$ref' output/multi-file.dereferenced-swagger.yaml | grep -v "'#/definitions/Node'"; then
+    echo 'References were left in the dereferenced spec'
+    exit_code=1
+fi
+
+echo 'Converting multi-file spec to 3.0 bundled into one file'
+docker run --rm -v "$PWD/specs/multi-file:/multi-file:ro" openapi-spec-converter:latest \
+    -t 3.0 -f yaml --bundle /multi-file/openapi.yaml \
+    > output/multi-file.bundled-30.yaml
+
+echo 'Validating multi-file spec converted to 3.0 bundled into one file'
+if ! node_modules/.bin/swagger-cli validate output/multi-file.bundled-30.yaml; then
+    exit_code=1
+fi
+
+echo 'Checking the bundled spec only references its own components'
+if grep '\$ref:' output/multi-file.bundled-30.yaml | grep -v '#/components/'; then
+    echo 'References to other files were left in the bundled spec'
+    exit_code=1
+fi
+
+echo 'Converting 3.0 parameter style spec to Swagger'
+docker run --rm -i openapi-spec-converter:latest -t swagger -f yaml \
+    < specs/30-parameter-styles.yaml \
+    > output/30-parameter-styles.converted-swagger.yaml
+
+echo 'Validating 3.0 parameter style spec converted to Swagger'
+if ! node_modules/.bin/swagger-cli validate output/30-parameter-styles.converted-swagger.yaml; then
+    exit_code=1
+fi
+
+echo 'Converting 3.1 $ref siblings spec to 3.0'
+docker run --rm -i openapi-spec-converter:latest -t 3.0 -f yaml \
+    < specs/31-ref-siblings.yaml \
+    > output/31-ref-siblings.converted-30.yaml
+
+echo 'Validating 3.1 $ref siblings spec converted to 3.0'
+if ! node_modules/.bin/swagger-cli validate output/31-ref-siblings.converted-30.yaml; then
+    exit_code=1
+fi
+
+echo 'Converting 3.1 tuple spec to 3.0'
+docker run --rm -i openapi-spec-converter:latest -t 3.0 -f yaml \
+    < specs/31-tuples.yaml \
+    > output/31-tuples.converted-30.yaml
+
+echo 'Validating 3.1 tuple spec converted to 3.0'
+if ! node_modules/.bin/swagger-cli validate output/31-tuples.converted-30.yaml; then
+    exit_code=1
+fi
+
+echo 'Converting 3.1 tuple spec to Swagger'
+docker run --rm -i openapi-spec-converter:latest -t swagger -f yaml \
+    < specs/31-tuples.yaml \
+    > output/31-tuples.converted-swagger.yaml
+
+echo 'Validating 3.1 tuple spec converted to Swagger'
+if ! node_modules/.bin/swagger-cli validate output/31-tuples.converted-swagger.yaml; then
+    exit_code=1
+fi
+
+echo 'Converting 3.2 spec to 3.0'
+docker run --rm -i openapi-spec-converter:latest -t 3.0 -f yaml \
+    < specs/32-spec.yaml \
+    > output/32-spec.converted-30.yaml
+
+echo 'Validating 3.2 spec converted to 3.0'
+if ! node_modules/.bin/swagger-cli validate output/32-spec.converted-30.yaml; then
+    exit_code=1
+fi
+
+echo 'Comparing a Swagger spec with a 3.1 spec'
+diff_code=0
+docker run --rm -v "$PWD/specs/diff:/diff:ro" openapi-spec-converter:latest \
+    diff /diff/old.yaml /diff/new.yaml \
+    > output/diff.txt || diff_code=$?
+
+echo 'Checking the changes between the specs are listed'
+if [ "$diff_code" != 1 ]; then
+    echo "Expected exit status 1 for breaking changes, got $diff_code"
+    exit_code=1
+fi
+
+if ! diff specs/diff/expected.txt output/diff.txt; then
+    exit_code=1
+fi
+
+echo 'Exporting schemas from a 3.1 spec as JSON Schema files'
+rm -rf output/exported-schemas
+docker run --rm -i -v "$PWD/output:/output" openapi-spec-converter:latest \
+    schemas --out-dir /output/exported-schemas - \
+    < specs/31-spec-with-differences-from-30.yaml
+
+echo 'Checking exported JSON Schema files have no OpenAPI keywords'
+if grep -r '"discriminator"\|"xml"\|"externalDocs"\|"example"\|"x-' output/exported-schemas; then
+    exit_code=1
+fi
+
+echo 'Importing exported JSON Schema files into a 3.0 spec'
+docker run --rm -v "$PWD/output/exported-schemas:/exported-schemas:ro" openapi-spec-converter:latest \
+    import-schemas -t 3.0 -f yaml /exported-schemas \
+    > output/exported-schemas.converted-30.yaml
+
+echo 'Validating exported JSON Schema files imported into a 3.0 spec'
+if ! node_modules/.bin/swagger-cli validate output/exported-schemas.converted-30.yaml; then
+    exit_code=1
+fi
+
+echo 'Converting 3.1 spec to a Postman Collection'
+docker run --rm -i openapi-spec-converter:latest -t postman \
+    < specs/31-spec-with-differences-from-30.yaml \
+    > output/31-spec-with-differences-from-30.postman.json
+
+echo 'Checking the Postman Collection has a request for every operation'
+if ! node -e '
+const collection = JSON.parse(require("fs").readFileSync(process.argv[1], "utf8"));
+const requests = [];
+const collect = items => items.forEach(item => item.item ? collect(item.item) : requests.push(item));
+
+if (collection.info.schema !== "https://schema.getpostman.com/json/collection/v2.1.0/collection.json") {
+    throw new Error("Unexpected collection schema: " + collection.info.schema);
+}
+
+collect(collection.item);
+
+for (const item of requests) {
+    if (!item.name || !item.request || !item.request.method || !item.request.url || !item.request.url.raw) {
+        throw new Error("Invalid request: " + JSON.stringify(item.name));
+    }
+}
+
+if (requests.length === 0) {
+    throw new Error("The collection has no requests");
+}
+' output/31-spec-with-differences-from-30.postman.json; then
+    exit_code=1
+fi
+
+echo 'Importing JSON Schema files into a 3.0 spec'
+docker run --rm -v "$PWD/specs/json-schemas:/json-schemas:ro" openapi-spec-converter:latest \
+    import-schemas -t 3.0 -f yaml /json-schemas \
+    > output/json-schemas.converted-30.yaml
+
+echo 'Validating JSON Schema files imported into a 3.0 spec'
+if ! node_modules/.bin/swagger-cli validate output/json-schemas.converted-30.yaml; then
+    exit_code=1
+fi
+
+echo 'Checking draft-07 dependencies are kept in JSON Schema files imported into a 3.0 spec'
+if ! grep -q 'not:' output/json-schemas.converted-30.yaml \
+    || grep -q 'dependentRequired\|dependencies:' output/json-schemas.converted-30.yaml; then
+    echo 'The dependencies of the owner schema were not converted to allOf'
+    exit_code=1
+fi
+
+echo 'Adding generated examples to a 3.0 spec'
+docker run --rm -i openapi-spec-converter:latest examples -t 3.0 -f yaml - \
+    < specs/31-spec-with-differences-from-30.yaml \
+    > output/31-spec-with-differences-from-30.examples-30.yaml
+
+echo 'Validating 3.0 spec with generated examples'
+if ! node_modules/.bin/swagger-cli validate output/31-spec-with-differences-from-30.examples-30.yaml; then
+    exit_code=1
+fi
+
+echo 'Starting the conversion server'
+docker run --rm -d --name openapi-spec-converter-server -p 8080:8080 openapi-spec-converter:latest serve > /dev/null
+
+for _ in $(seq 1 30); do
+    if curl -s -o /dev/null http://localhost:8080/convert; then
+        break
+    fi
+
+    sleep 1
+done
+
+echo 'Converting 3.1 spec to 3.0 with the conversion server'
+if ! curl -sf --data-binary @specs/31-spec-with-differences-from-30.yaml \
+    -H 'Accept: application/json;q=0.5, application/yaml' \
+    'http://localhost:8080/convert?target=3.0' \
+    > output/31-spec-with-differences-from-30.served-30.yaml; then
+    echo 'The conversion server failed to convert the spec'
+    exit_code=1
+fi
+
+echo 'Validating 3.1 spec converted to 3.0 with the conversion server'
+if ! grep -q '^openapi: 3\.0' output/31-spec-with-differences-from-30.served-30.yaml \
+    || ! node_modules/.bin/swagger-cli validate output/31-spec-with-differences-from-30.served-30.yaml; then
+    exit_code=1
+fi
+
+echo 'Checking the conversion server rejects formats with a quality of 0'
+status="$(curl -s -o /dev/null -w '%{http_code}' --data-binary @specs/31-spec-with-differences-from-30.yaml \
+    -H 'Accept: application/json;q=0, application/yaml;q=0.000' \
+    'http://localhost:8080/convert?target=3.0' || true)"
+
+if [ "$status" != 406 ]; then
+    echo "Expected status 406, got $status"
+    exit_code=1
+fi
+
+docker stop openapi-spec-converter-server > /dev/null
+
 exit $exit_code
@@ -0,0 +1,560 @@
+package openapispecconverter
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	ghodssYaml "github.com/ghodss/yaml"
+	"gopkg.in/yaml.v3"
+)
+
+// SpecVersion is a version of the Swagger or OpenAPI specification.
+type SpecVersion int
+
+const (
+	Swagger SpecVersion = iota
+	OpenAPI30
+	OpenAPI31
+	OpenAPI32
+)
+
+// Exact versions of the OpenAPI specification documents can be converted to.
+//
+// Converting to OpenAPI30, OpenAPI31, or OpenAPI32 uses the latest patch version.
+const (
+	OpenAPI300 SpecVersion = iota + 100
+	OpenAPI301
+	OpenAPI302
+	OpenAPI303
+	OpenAPI304
+	OpenAPI310
+	OpenAPI311
+	OpenAPI320
+)
+
+// Postman is a Postman Collection v2.1, which documents can be converted to, but not from.
+const Postman SpecVersion = 200
+
+// exactVersions maps exact versions to their version strings.
+var exactVersions = map[SpecVersion]string{
+	OpenAPI300: "3.0.0",
+	OpenAPI301: "3.0.1",
+	OpenAPI302: "3.0.2",
+	OpenAPI303: "3.0.3",
+	OpenAPI304: "3.0.4",
+	OpenAPI310: "3.1.0",
+	OpenAPI311: "3.1.1",
+	OpenAPI320: "3.2.0",
+}
+
+func (version SpecVersion) String() string {
+	switch version {
+	case Swagger:
+		return "swagger"
+	case OpenAPI30:
+		return "3.0"
+	case OpenAPI31:
+		return "3.1"
+	case OpenAPI32:
+		return "3.2"
+	case Postman:
+		return "postman"
+	}
+
+	if name, ok := exactVersions[version]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("SpecVersion(%d)", int(version))
+}
+
+// minorVersion returns the version without a patch version, which conversion steps convert between.
+func (version SpecVersion) minorVersion() SpecVersion {
+	switch {
+	case version >= OpenAPI300 && version <= OpenAPI304:
+		return OpenAPI30
+	case version >= OpenAPI310 && version <= OpenAPI311:
+		return OpenAPI31
+	case version == OpenAPI320:
+		return OpenAPI32
+	default:
+		return version
+	}
+}
+
+// ParseSpecVersion parses a target version name: swagger, 3.0, 3.1, 3.2, postman, or an exact version such as 3.0.3
+func ParseSpecVersion(name string) (SpecVersion, error) {
+	switch strings.ToLower(name) {
+	case "postman":
+		return Postman, nil
+	case "swagger":
+		return Swagger, nil
+	case "3.0":
+		return OpenAPI30, nil
+	case "3.1":
+		return OpenAPI31, nil
+	case "3.2":
+		return OpenAPI32, nil
+	}
+
+	for version, versionName := range exactVersions {
+		if name == versionName {
+			return version, nil
+		}
+	}
+
+	return 0, fmt.Errorf("Invalid target version %s", name)
+}
+
+// Format is a serialization format for documents.
+type Format int
+
+const (
+	JSON Format = iota
+	YAML
+)
+
+func (format Format) String() string {
+	switch format {
+	case JSON:
+		return "json"
+	case YAML:
+		return "yaml"
+	default:
+		return fmt.Sprintf("Format(%d)", int(format))
+	}
+}
+
+// ParseFormat parses a format name: yaml or json
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "json":
+		return JSON, nil
+	case "yaml":
+		return YAML, nil
+	default:
+		return 0, fmt.Errorf("Invalid format: %s", name)
+	}
+}
+
+// Converter converts Swagger and OpenAPI documents to a target version and format.
+type Converter struct {
+	target      SpecVersion
+	format      Format
+	report      *Report
+	bundle      BundleMode
+	dereference DereferenceMode
+	options     conversionOptions
+	// inputVersion is used instead of the version in documents, if set.
+	inputVersion *SpecVersion
+	// fsys and documentPath are used for resolving references to other files.
+	fsys         fs.FS
+	documentPath string
+}
+
+// Option configures a Converter.
+type Option func(converter *Converter)
+
+// WithTarget sets the version documents will be converted to. The default is OpenAPI 3.1.
+func WithTarget(target SpecVersion) Option {
+	return func(converter *Converter) {
+		converter.target = target
+	}
+}
+
+// WithInputVersion sets the version of input documents, instead of detecting it from the document.
+// The version in the document is replaced, so documents with invalid versions can be converted.
+func WithInputVersion(version SpecVersion) Option {
+	return func(converter *Converter) {
+		converter.inputVersion = &version
+	}
+}
+
+// WithFormat sets the format converted documents will be output in. The default is JSON.
+func WithFormat(format Format) Option {
+	return func(converter *Converter) {
+		converter.format = format
+	}
+}
+
+// WithReport sets a Report to collect diagnostics describing every change made during conversion.
+func WithReport(report *Report) Option {
+	return func(converter *Converter) {
+		converter.report = report
+	}
+}
+
+// WithBundle sets how references to other files are bundled into the document before conversion.
+// The default is BundleNone.
+func WithBundle(mode BundleMode) Option {
+	return func(converter *Converter) {
+		converter.bundle = mode
+	}
+}
+
+// WithDereference sets if every `$ref` should be replaced with the content it references after conversion.
+// The default is DereferenceNone.
+//
+// Dereferencing implies BundleComponents if no other bundle mode is set,
+// as references to other files must be resolved before the document can be converted.
+func WithDereference(mode DereferenceMode) Option {
+	return func(converter *Converter) {
+		converter.dereference = mode
+	}
+}
+
+// WithEnumToConst sets if single value enums should be replaced with const when converting to 3.1.
+// The default is false.
+func WithEnumToConst(enabled bool) Option {
+	return func(converter *Converter) {
+		converter.options.enumToConst = enabled
+	}
+}
+
+// WithCollapseAllOfRefs sets if `allOf` wrappers for a single `$ref` should be replaced with
+// `$ref` and sibling keywords when converting to 3.1.
+// The default is false.
+func WithCollapseAllOfRefs(enabled bool) Option {
+	return func(converter *Converter) {
+		converter.options.collapseAllOfRefs = enabled
+	}
+}
+
+// WithWebhooksExtension sets if webhooks should be moved to an `x-webhooks` extension when converting to 3.0,
+// and the `x-webhooks` extension moved back to webhooks when converting to 3.1.
+// The default is false, which removes webhooks when converting to 3.0.
+func WithWebhooksExtension(enabled bool) Option {
+	return func(converter *Converter) {
+		converter.options.webhooksExtension = enabled
+	}
+}
+
+// WithFS sets the filesystem references to other files are loaded from,
+// and the path of the document being converted in that filesystem.
+// The default is the current working directory.
+func WithFS(fsys fs.FS, documentPath string) Option {
+	return func(converter *Converter) {
+		converter.fsys = fsys
+		converter.documentPath = documentPath
+	}
+}
+
+// WithBasePath resolves references to other files on the local filesystem
+// relative to the document at documentPath.
+func WithBasePath(documentPath string) Option {
+	if absPath, err := filepath.Abs(documentPath); err == nil {
+		// Use the root of the filesystem so references can point to parent directories.
+		root := filepath.VolumeName(absPath) + string(filepath.Separator)
+
+		if relPath, err := filepath.Rel(root, absPath); err == nil {
+			return WithFS(os.DirFS(root), filepath.ToSlash(relPath))
+		}
+	}
+
+	return WithFS(os.DirFS(filepath.Dir(documentPath)), filepath.Base(documentPath))
+}
+
+// NewConverter creates a Converter with the given options.
+func NewConverter(options ...Option) *Converter {
+	converter := &Converter{
+		target:       OpenAPI31,
+		format:       JSON,
+		fsys:         os.DirFS("."),
+		documentPath: ".",
+	}
+
+	for _, option := range options {
+		option(converter)
+	}
+
+	return converter
+}
+
+// Convert converts a Swagger or OpenAPI document in JSON or YAML format.
+func (converter *Converter) Convert(data []byte) ([]byte, error) {
+	var err error
+
+	if converter.inputVersion != nil {
+		data, err = setInputVersion(data, *converter.inputVersion, converter.report)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	bundle := converter.bundle
+
+	if bundle == BundleNone && converter.dereference != DereferenceNone {
+		bundle = BundleComponents
+	}
+
+	if bundle != BundleNone {
+		data, err = bundleDocument(data, converter.fsys, converter.documentPath, bundle)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	data, err = convertDocument(data, converter.target, converter.report, converter.options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Postman collections have no references, as every example is written out in full.
+	if converter.dereference != DereferenceNone && converter.target != Postman {
+		data, err = dereferenceDocument(data, converter.fsys, converter.documentPath, converter.dereference)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ConvertFormat(data, converter.format)
+}
+
+// versionPattern matches `major.minor` versions, with an optional patch version and suffix.
+var versionPattern = regexp.MustCompile(`^(\d+)\.(\d+)(\.\d+)?([-+].*)?$`)
+
+// DetectVersion determines the specification version of a Swagger or OpenAPI document.
+//
+// Any 3.0.x, 3.1.x, or 3.2.x version is accepted, including versions without a patch version.
+func DetectVersion(data []byte) (SpecVersion, error) {
+	var root yaml.Node
+
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return 0, &ParseError{Err: err}
+	}
+
+	// Get the version string from the Swagger field if there is no OpenAPI field.
+	field := "openapi"
+	versionNode := mappingValue(documentRoot(&root), field)
+
+	if versionNode == nil {
+		field = "swagger"
+		versionNode = mappingValue(documentRoot(&root), field)
+	}
+
+	if versionNode == nil {
+		return 0, &UnsupportedVersionError{}
+	}
+
+	if match := versionPattern.FindStringSubmatch(versionNode.Value); match != nil && versionNode.Kind == yaml.ScalarNode {
+		switch {
+		case field == "swagger" && match[1] == "2" && match[2] == "0":
+			return Swagger, nil
+		case field == "openapi" && match[1] == "3" && match[2] == "0":
+			return OpenAPI30, nil
+		case field == "openapi" && match[1] == "3" && match[2] == "1":
+			return OpenAPI31, nil
+		case field == "openapi" && match[1] == "3" && match[2] == "2":
+			return OpenAPI32, nil
+		}
+	}
+
+	return 0, &UnsupportedVersionError{
+		Version: versionNode.Value,
+		Pointer: "/" + field,
+		Line:    versionNode.Line,
+	}
+}
+
+// versionName returns the version string for a version, using the latest patch version for minor versions.
+func (version SpecVersion) versionName() string {
+	switch version {
+	case Swagger:
+		return "2.0"
+	case OpenAPI30:
+		return exactVersions[OpenAPI304]
+	case OpenAPI31:
+		return exactVersions[OpenAPI311]
+	case OpenAPI32:
+		return exactVersions[OpenAPI320]
+	default:
+		return exactVersions[version]
+	}
+}
+
+// setInputVersion replaces the version of a document, so documents with invalid versions can be converted.
+func setInputVersion(data []byte, version SpecVersion, report *Report) ([]byte, error) {
+	if version == Postman {
+		return nil, fmt.Errorf("Postman collections cannot be converted to other versions")
+	}
+
+	field := "openapi"
+
+	if version == Swagger {
+		field = "swagger"
+	}
+
+	return patchDocument(data, []string{""}, func(node *yaml.Node, pointer string) {
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+
+		var before any
+		content := []*yaml.Node{
+			{Kind: yaml.ScalarNode, Tag: "!!str", Value: field},
+			{Kind: yaml.ScalarNode, Tag: "!!str", Value: version.versionName()},
+		}
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if key := node.Content[i].Value; key == "openapi" || key == "swagger" {
+				before = nodeValue(node.Content[i+1])
+			} else {
+				content = append(content, node.Content[i], node.Content[i+1])
+			}
+		}
+
+		report.Add(Diagnostic{
+			Pointer:  "/" + field,
+			Rule:     "input-version",
+			Severity: SeverityInfo,
+			Message:  "Set the input document version to " + version.versionName(),
+			Before:   before,
+			After:    version.versionName(),
+		})
+
+		node.Content = content
+	})
+}
+
+// conversionOptions enables optional rewrites in conversion steps.
+type conversionOptions struct {
+	// enumToConst replaces single value enums with const when converting 3.0 to 3.1.
+	enumToConst bool
+	// collapseAllOfRefs replaces `allOf` wrappers for a single `$ref` with `$ref` when converting 3.0 to 3.1.
+	collapseAllOfRefs bool
+	// webhooksExtension moves webhooks to and from `x-webhooks` when converting between 3.0 and 3.1.
+	webhooksExtension bool
+}
+
+func convertDocument(data []byte, target SpecVersion, report *Report, options conversionOptions) ([]byte, error) {
+	// Postman collections are created from 3.1 documents after every other step.
+	if target == Postman {
+		data, err := convertDocument(data, OpenAPI31, report, options)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if data, err = convertOpenAPIToPostman(data, report); err != nil {
+			return nil, &ConversionError{From: OpenAPI31, To: Postman, Err: err}
+		}
+
+		return data, nil
+	}
+
+	inputVersion, err := DetectVersion(data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	outputVersion := target.minorVersion()
+
+	// Cycle through document versions until we hit the one we want.
+	for inputVersion != outputVersion {
+		fromVersion := inputVersion
+
+		if inputVersion < outputVersion {
+			switch inputVersion {
+			case Swagger:
+				data, err = convertSwaggerToOpenAPI30(data, report)
+				inputVersion = OpenAPI30
+			case OpenAPI30:
+				data, err = convertOpenAPI30To31(data, report, options)
+				inputVersion = OpenAPI31
+			default:
+				data, err = convertOpenAPI31To32(data, report)
+				inputVersion = OpenAPI32
+			}
+		} else {
+			switch inputVersion {
+			case OpenAPI32:
+				data, err = convertOpenAPI32To31(data, report)
+				inputVersion = OpenAPI31
+			case OpenAPI31:
+				data, err = convertOpenAPI31To30(data, report, options)
+				inputVersion = OpenAPI30
+			default:
+				data, err = convertOpenAPI30ToSwagger(data, report)
+				inputVersion = Swagger
+			}
+		}
+
+		if err != nil {
+			return nil, &ConversionError{From: fromVersion, To: inputVersion, Err: err}
+		}
+	}
+
+	if versionName, ok := exactVersions[target]; ok {
+		if data, err = setPatchVersion(data, versionName, report); err != nil {
+			return nil, &ConversionError{From: outputVersion, To: target, Err: err}
+		}
+	}
+
+	return data, nil
+}
+
+// DetectFormat guesses if data is JSON or YAML by looking at the first non-whitespace character.
+func DetectFormat(data []byte) Format {
+	for _, b := range data {
+		switch b {
+		case '{':
+			return JSON
+		case ' ', '\t', '\r', '\n':
+		default:
+			return YAML
+		}
+	}
+
+	return YAML
+}
+
+// ConvertFormat converts JSON or YAML data to the given format, if it isn't in that format already.
+func ConvertFormat(data []byte, format Format) ([]byte, error) {
+	if DetectFormat(data) == format {
+		return data, nil
+	}
+
+	var err error
+
+	if format == JSON {
+		data, err = ghodssYaml.YAMLToJSON(data)
+	} else {
+		data, err = ghodssYaml.JSONToYAML(data)
+	}
+
+	if err != nil {
+		return nil, &FormatError{Format: format, Err: err}
+	}
+
+	return data, nil
+}
+
+// setPatchVersion sets the exact OpenAPI version of a document.
+//
+// Patch versions of the specification only clarify it, and don't add or remove features,
+// so only the version needs to change.
+func setPatchVersion(data []byte, versionName string, report *Report) ([]byte, error) {
+	return patchDocument(data, []string{""}, func(node *yaml.Node, pointer string) {
+		if version := mappingValue(node, "openapi"); version != nil && version.Value != versionName {
+			report.Add(Diagnostic{
+				Pointer:  "/openapi",
+				Rule:     "patch-version",
+				Severity: SeverityInfo,
+				Message:  "Set the OpenAPI version to " + versionName,
+				Before:   version.Value,
+				After:    versionName,
+			})
+
+			version.Value = versionName
+		}
+	})
+}
@@ -0,0 +1,62 @@
+package openapispecconverter
+
+import (
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// DereferenceMode sets if and how every `$ref` in a converted document is replaced with the content it references.
+type DereferenceMode int
+
+const (
+	// DereferenceNone leaves references as they are.
+	DereferenceNone DereferenceMode = iota
+	// DereferenceKeepCycles inlines every reference, but keeps a single `$ref` where references form a cycle.
+	DereferenceKeepCycles
+	// DereferenceStrict inlines every reference, and fails with a *CircularReferenceError if references form a cycle.
+	DereferenceStrict
+)
+
+func (mode DereferenceMode) String() string {
+	switch mode {
+	case DereferenceNone:
+		return "none"
+	case DereferenceKeepCycles:
+		return "keep-cycles"
+	case DereferenceStrict:
+		return "strict"
+	default:
+		return fmt.Sprintf("DereferenceMode(%d)", int(mode))
+	}
+}
+
+// ParseDereferenceMode parses a dereference mode name: none, keep-cycles, or strict
+func ParseDereferenceMode(name string) (DereferenceMode, error) {
+	switch strings.ToLower(name) {
+	case "none":
+		return DereferenceNone, nil
+	case "keep-cycles":
+		return DereferenceKeepCycles, nil
+	case "strict":
+		return DereferenceStrict, nil
+	default:
+		return 0, fmt.Errorf("Invalid dereference mode: %s", name)
+	}
+}
+
+// dereferenceDocument replaces every `$ref` in a document with the content it references.
+//
+// References to other files are loaded from fsys relative to documentPath.
+// Remote references are left as they are.
+func dereferenceDocument(data []byte, fsys fs.FS, documentPath string, mode DereferenceMode) ([]byte, error) {
+	bundler := bundler{
+		fsys:        fsys,
+		mode:        BundleInline,
+		rootPath:    documentPath,
+		dereference: true,
+		keepCycles:  mode == DereferenceKeepCycles,
+	}
+
+	return bundler.run(data)
+}
@@ -0,0 +1,805 @@
+package openapispecconverter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/pb33f/libopenapi"
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/libopenapi/orderedmap"
+	"gopkg.in/yaml.v3"
+)
+
+// ChangeKind describes how part of a document changed between two documents.
+type ChangeKind int
+
+const (
+	ChangeAdded ChangeKind = iota
+	ChangeRemoved
+	ChangeModified
+)
+
+func (kind ChangeKind) String() string {
+	switch kind {
+	case ChangeAdded:
+		return "added"
+	case ChangeRemoved:
+		return "removed"
+	case ChangeModified:
+		return "changed"
+	default:
+		return fmt.Sprintf("ChangeKind(%d)", int(kind))
+	}
+}
+
+func (kind ChangeKind) MarshalJSON() ([]byte, error) {
+	return json.Marshal(kind.String())
+}
+
+// Change describes a single difference between two documents.
+type Change struct {
+	// Pointer is a JSON pointer to the change in the new document, or the old document for removals.
+	// Pointers into schemas follow references to components.
+	Pointer  string     `json:"pointer"`
+	Kind     ChangeKind `json:"kind"`
+	Breaking bool       `json:"breaking"`
+	Message  string     `json:"message"`
+	Before   any        `json:"before,omitempty"`
+	After    any        `json:"after,omitempty"`
+}
+
+// DiffResult lists every change between two documents.
+type DiffResult struct {
+	Changes []Change
+}
+
+// HasBreakingChanges returns true if any change could break existing clients.
+func (result *DiffResult) HasBreakingChanges() bool {
+	for _, change := range result.Changes {
+		if change.Breaking {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MarshalJSON renders the result as a JSON list of changes.
+func (result *DiffResult) MarshalJSON() ([]byte, error) {
+	if result.Changes == nil {
+		return []byte("[]"), nil
+	}
+
+	return json.Marshal(result.Changes)
+}
+
+// schemaDirection says if a schema describes data sent to or returned from an API,
+// which decides which changes to the schema are breaking.
+type schemaDirection int
+
+const (
+	schemaRequest schemaDirection = iota
+	schemaResponse
+)
+
+type differ struct {
+	result  *DiffResult
+	visited map[string]bool
+}
+
+// mapGet gets a value from an ordered map that may be nil.
+func mapGet[K comparable, V any](m *orderedmap.Map[K, V], key K) (V, bool) {
+	if m == nil {
+		var zero V
+
+		return zero, false
+	}
+
+	return m.Get(key)
+}
+
+func (differ *differ) add(change Change) {
+	differ.result.Changes = append(differ.result.Changes, change)
+}
+
+func loadDiffDocument(data []byte) (*libopenapi.DocumentModel[v3.Document], error) {
+	// Both documents are compared as OpenAPI 3.1, whatever version they start as.
+	data, err := convertDocument(data, OpenAPI31, nil, conversionOptions{})
+
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := libopenapi.NewDocument(data)
+
+	if err != nil {
+		return nil, fmt.Errorf("Error loading document: %w", err)
+	}
+
+	model, errs := doc.BuildV3Model()
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("Errors loading document: %w", errors.Join(errs...))
+	}
+
+	return model, nil
+}
+
+// Diff compares two Swagger or OpenAPI documents of any version, and lists
+// the paths, operations, parameters, and schemas that were added, removed, or changed.
+func Diff(oldData []byte, newData []byte) (*DiffResult, error) {
+	oldModel, err := loadDiffDocument(oldData)
+
+	if err != nil {
+		return nil, fmt.Errorf("Error reading old document: %w", err)
+	}
+
+	newModel, err := loadDiffDocument(newData)
+
+	if err != nil {
+		return nil, fmt.Errorf("Error reading new document: %w", err)
+	}
+
+	differ := &differ{result: &DiffResult{}, visited: map[string]bool{}}
+	var oldPaths, newPaths *orderedmap.Map[string, *v3.PathItem]
+
+	if oldModel.Model.Paths != nil {
+		oldPaths = oldModel.Model.Paths.PathItems
+	}
+
+	if newModel.Model.Paths != nil {
+		newPaths = newModel.Model.Paths.PathItems
+	}
+
+	differ.diffPaths(oldPaths, newPaths)
+
+	return differ.result, nil
+}
+
+func (differ *differ) diffPaths(oldPaths *orderedmap.Map[string, *v3.PathItem], newPaths *orderedmap.Map[string, *v3.PathItem]) {
+	for path, oldPathItem := range oldPaths.FromOldest() {
+		pointer := joinPointer("", "paths", path)
+
+		if newPathItem, ok := mapGet(newPaths, path); ok {
+			differ.diffPathItem(pointer, path, oldPathItem, newPathItem)
+		} else {
+			differ.add(Change{
+				Pointer:  pointer,
+				Kind:     ChangeRemoved,
+				Breaking: true,
+				Message:  fmt.Sprintf("Removed path %s", path),
+			})
+		}
+	}
+
+	for path := range newPaths.KeysFromOldest() {
+		if _, ok := mapGet(oldPaths, path); !ok {
+			differ.add(Change{
+				Pointer: joinPointer("", "paths", path),
+				Kind:    ChangeAdded,
+				Message: fmt.Sprintf("Added path %s", path),
+			})
+		}
+	}
+}
+
+func (differ *differ) diffPathItem(pointer string, path string, oldPathItem *v3.PathItem, newPathItem *v3.PathItem) {
+	oldOperations := oldPathItem.GetOperations()
+	newOperations := newPathItem.GetOperations()
+
+	for method, oldOperation := range oldOperations.FromOldest() {
+		operationPointer := joinPointer(pointer, method)
+		name := strings.ToUpper(method) + " " + path
+
+		if newOperation, ok := newOperations.Get(method); ok {
+			differ.diffOperation(
+				operationPointer,
+				name,
+				collectParameters(pointer, oldPathItem.Parameters, operationPointer, oldOperation.Parameters),
+				collectParameters(pointer, newPathItem.Parameters, operationPointer, newOperation.Parameters),
+				oldOperation,
+				newOperation,
+			)
+		} else {
+			differ.add(Change{
+				Pointer:  operationPointer,
+				Kind:     ChangeRemoved,
+				Breaking: true,
+				Message:  fmt.Sprintf("Removed operation %s", name),
+			})
+		}
+	}
+
+	for method := range newOperations.KeysFromOldest() {
+		if _, ok := oldOperations.Get(method); !ok {
+			differ.add(Change{
+				Pointer: joinPointer(pointer, method),
+				Kind:    ChangeAdded,
+				Message: fmt.Sprintf("Added operation %s %s", strings.ToUpper(method), path),
+			})
+		}
+	}
+}
+
+// diffParameter is a parameter for an operation, and its location in a document.
+type diffParameter struct {
+	pointer   string
+	parameter *v3.Parameter
+}
+
+// collectParameters merges path and operation parameters, keyed by location and name.
+func collectParameters(
+	pathPointer string,
+	pathParameters []*v3.Parameter,
+	operationPointer string,
+	operationParameters []*v3.Parameter,
+) *orderedmap.Map[string, diffParameter] {
+	parameters := orderedmap.New[string, diffParameter]()
+
+	for i, parameter := range pathParameters {
+		parameters.Set(
+			parameter.In+" "+parameter.Name,
+			diffParameter{pointer: joinPointer(pathPointer, "parameters", fmt.Sprint(i)), parameter: parameter},
+		)
+	}
+
+	// Operation parameters override path parameters with the same name.
+	for i, parameter := range operationParameters {
+		parameters.Set(
+			parameter.In+" "+parameter.Name,
+			diffParameter{pointer: joinPointer(operationPointer, "parameters", fmt.Sprint(i)), parameter: parameter},
+		)
+	}
+
+	return parameters
+}
+
+func (differ *differ) diffOperation(
+	pointer string,
+	name string,
+	oldParameters *orderedmap.Map[string, diffParameter],
+	newParameters *orderedmap.Map[string, diffParameter],
+	oldOperation *v3.Operation,
+	newOperation *v3.Operation,
+) {
+	for key, oldParameter := range oldParameters.FromOldest() {
+		if newParameter, ok := newParameters.Get(key); ok {
+			differ.diffParameter(newParameter.pointer, oldParameter.parameter, newParameter.parameter)
+		} else {
+			differ.add(Change{
+				Pointer: oldParameter.pointer,
+				Kind:    ChangeRemoved,
+				Message: fmt.Sprintf("Removed %s parameter %q from %s", oldParameter.parameter.In, oldParameter.parameter.Name, name),
+			})
+		}
+	}
+
+	for key, newParameter := range newParameters.FromOldest() {
+		if _, ok := oldParameters.Get(key); !ok {
+			required := newParameter.parameter.Required != nil && *newParameter.parameter.Required
+
+			differ.add(Change{
+				Pointer:  newParameter.pointer,
+				Kind:     ChangeAdded,
+				Breaking: required,
+				Message:  fmt.Sprintf("Added %s parameter %q to %s", newParameter.parameter.In, newParameter.parameter.Name, name),
+				After:    map[string]any{"required": required},
+			})
+		}
+	}
+
+	differ.diffRequestBody(joinPointer(pointer, "requestBody"), oldOperation.RequestBody, newOperation.RequestBody)
+
+	if oldOperation.Responses != nil && newOperation.Responses != nil {
+		differ.diffResponses(joinPointer(pointer, "responses"), name, oldOperation.Responses, newOperation.Responses)
+	}
+}
+
+func (differ *differ) diffParameter(pointer string, oldParameter *v3.Parameter, newParameter *v3.Parameter) {
+	oldRequired := oldParameter.Required != nil && *oldParameter.Required
+	newRequired := newParameter.Required != nil && *newParameter.Required
+
+	if oldRequired != newRequired {
+		message := fmt.Sprintf("Parameter %q is now required", newParameter.Name)
+
+		if !newRequired {
+			message = fmt.Sprintf("Parameter %q is no longer required", newParameter.Name)
+		}
+
+		differ.add(Change{
+			Pointer:  joinPointer(pointer, "required"),
+			Kind:     ChangeModified,
+			Breaking: newRequired,
+			Message:  message,
+			Before:   oldRequired,
+			After:    newRequired,
+		})
+	}
+
+	differ.diffSchema(joinPointer(pointer, "schema"), oldParameter.Schema, newParameter.Schema, schemaRequest)
+}
+
+func (differ *differ) diffRequestBody(pointer string, oldRequestBody *v3.RequestBody, newRequestBody *v3.RequestBody) {
+	if oldRequestBody == nil && newRequestBody == nil {
+		return
+	}
+
+	if oldRequestBody == nil {
+		required := newRequestBody.Required != nil && *newRequestBody.Required
+
+		differ.add(Change{
+			Pointer:  pointer,
+			Kind:     ChangeAdded,
+			Breaking: required,
+			Message:  "Added a request body",
+			After:    map[string]any{"required": required},
+		})
+
+		return
+	}
+
+	if newRequestBody == nil {
+		differ.add(Change{
+			Pointer: pointer,
+			Kind:    ChangeRemoved,
+			Message: "Removed the request body",
+		})
+
+		return
+	}
+
+	oldRequired := oldRequestBody.Required != nil && *oldRequestBody.Required
+	newRequired := newRequestBody.Required != nil && *newRequestBody.Required
+
+	if oldRequired != newRequired {
+		message := "The request body is now required"
+
+		if !newRequired {
+			message = "The request body is no longer required"
+		}
+
+		differ.add(Change{
+			Pointer:  joinPointer(pointer, "required"),
+			Kind:     ChangeModified,
+			Breaking: newRequired,
+			Message:  message,
+			Before:   oldRequired,
+			After:    newRequired,
+		})
+	}
+
+	differ.diffContent(joinPointer(pointer, "content"), oldRequestBody.Content, newRequestBody.Content, schemaRequest)
+}
+
+func (differ *differ) diffResponses(pointer string, name string, oldResponses *v3.Responses, newResponses *v3.Responses) {
+	oldCodes := orderedmap.New[string, *v3.Response]()
+	newCodes := orderedmap.New[string, *v3.Response]()
+
+	for code, response := range oldResponses.Codes.FromOldest() {
+		oldCodes.Set(code, response)
+	}
+
+	for code, response := range newResponses.Codes.FromOldest() {
+		newCodes.Set(code, response)
+	}
+
+	if oldResponses.Default != nil {
+		oldCodes.Set("default", oldResponses.Default)
+	}
+
+	if newResponses.Default != nil {
+		newCodes.Set("default", newResponses.Default)
+	}
+
+	for code, oldResponse := range oldCodes.FromOldest() {
+		codePointer := joinPointer(pointer, code)
+
+		if newResponse, ok := newCodes.Get(code); ok {
+			differ.diffContent(joinPointer(codePointer, "content"), oldResponse.Content, newResponse.Content, schemaResponse)
+		} else {
+			differ.add(Change{
+				Pointer:  codePointer,
+				Kind:     ChangeRemoved,
+				Breaking: true,
+				Message:  fmt.Sprintf("Removed the %s response from %s", code, name),
+			})
+		}
+	}
+
+	for code := range newCodes.KeysFromOldest() {
+		if _, ok := oldCodes.Get(code); !ok {
+			differ.add(Change{
+				Pointer: joinPointer(pointer, code),
+				Kind:    ChangeAdded,
+				Message: fmt.Sprintf("Added a %s response to %s", code, name),
+			})
+		}
+	}
+}
+
+func (differ *differ) diffContent(
+	pointer string,
+	oldContent *orderedmap.Map[string, *v3.MediaType],
+	newContent *orderedmap.Map[string, *v3.MediaType],
+	direction schemaDirection,
+) {
+	for mediaType, oldMediaType := range oldContent.FromOldest() {
+		mediaTypePointer := joinPointer(pointer, mediaType)
+
+		if newMediaType, ok := mapGet(newContent, mediaType); ok {
+			differ.diffSchema(joinPointer(mediaTypePointer, "schema"), oldMediaType.Schema, newMediaType.Schema, direction)
+		} else {
+			differ.add(Change{
+				Pointer:  mediaTypePointer,
+				Kind:     ChangeRemoved,
+				Breaking: true,
+				Message:  fmt.Sprintf("Removed media type %s", mediaType),
+			})
+		}
+	}
+
+	for mediaType, newMediaType := range newContent.FromOldest() {
+		if _, ok := mapGet(oldContent, mediaType); !ok {
+			differ.add(Change{
+				Pointer: joinPointer(pointer, mediaType),
+				Kind:    ChangeAdded,
+				Message: fmt.Sprintf("Added media type %s", mediaType),
+			})
+
+			// Swagger documents without consumes or produces are converted with */*,
+			// so compare the schemas when a single media type was replaced with another.
+			if orderedmap.Len(oldContent) == 1 && newContent.Len() == 1 {
+				differ.diffSchema(
+					joinPointer(pointer, mediaType, "schema"),
+					oldContent.First().Value().Schema,
+					newMediaType.Schema,
+					direction,
+				)
+			}
+		}
+	}
+}
+
+// enumValue renders an enum value as a string for comparison.
+func enumValue(node *yaml.Node) string {
+	if node.Kind == yaml.ScalarNode {
+		return node.Value
+	}
+
+	data, _ := yaml.Marshal(node)
+
+	return strings.TrimSpace(string(data))
+}
+
+func (differ *differ) diffSchema(
+	pointer string,
+	oldProxy *base.SchemaProxy,
+	newProxy *base.SchemaProxy,
+	direction schemaDirection,
+) {
+	if oldProxy == nil || newProxy == nil {
+		return
+	}
+
+	if newProxy.IsReference() && strings.HasPrefix(newProxy.GetReference(), "#") {
+		// Report changes to shared schemas where they are defined.
+		pointer = strings.TrimPrefix(newProxy.GetReference(), "#")
+	}
+
+	if oldProxy.IsReference() || newProxy.IsReference() {
+		// Compare each pair of referenced schemas once, which also stops recursive schemas.
+		key := fmt.Sprintf("%d %s %s %s", direction, pointer, oldProxy.GetReference(), newProxy.GetReference())
+
+		if differ.visited[key] {
+			return
+		}
+
+		differ.visited[key] = true
+	}
+
+	oldSchema := oldProxy.Schema()
+	newSchema := newProxy.Schema()
+
+	if oldSchema == nil || newSchema == nil {
+		return
+	}
+
+	differ.diffTypes(pointer, oldSchema, newSchema, direction)
+	differ.diffEnum(pointer, oldSchema, newSchema, direction)
+
+	if oldSchema.Format != newSchema.Format {
+		differ.add(Change{
+			Pointer: joinPointer(pointer, "format"),
+			Kind:    ChangeModified,
+			Message: fmt.Sprintf("Changed format from %q to %q", oldSchema.Format, newSchema.Format),
+			Before:  oldSchema.Format,
+			After:   newSchema.Format,
+		})
+	}
+
+	differ.diffLowerBound(pointer, "minimum", oldSchema.Minimum, newSchema.Minimum, direction)
+	differ.diffUpperBound(pointer, "maximum", oldSchema.Maximum, newSchema.Maximum, direction)
+	differ.diffLowerBound(pointer, "exclusiveMinimum", exclusiveBound(oldSchema.ExclusiveMinimum), exclusiveBound(newSchema.ExclusiveMinimum), direction)
+	differ.diffUpperBound(pointer, "exclusiveMaximum", exclusiveBound(oldSchema.ExclusiveMaximum), exclusiveBound(newSchema.ExclusiveMaximum), direction)
+	differ.diffLowerBound(pointer, "minLength", intBound(oldSchema.MinLength), intBound(newSchema.MinLength), direction)
+	differ.diffUpperBound(pointer, "maxLength", intBound(oldSchema.MaxLength), intBound(newSchema.MaxLength), direction)
+	differ.diffLowerBound(pointer, "minItems", intBound(oldSchema.MinItems), intBound(newSchema.MinItems), direction)
+	differ.diffUpperBound(pointer, "maxItems", intBound(oldSchema.MaxItems), intBound(newSchema.MaxItems), direction)
+	differ.diffLowerBound(pointer, "minProperties", intBound(oldSchema.MinProperties), intBound(newSchema.MinProperties), direction)
+	differ.diffUpperBound(pointer, "maxProperties", intBound(oldSchema.MaxProperties), intBound(newSchema.MaxProperties), direction)
+	differ.diffProperties(pointer, oldSchema, newSchema, direction)
+
+	if oldSchema.Items != nil && newSchema.Items != nil && oldSchema.Items.IsA() && newSchema.Items.IsA() {
+		differ.diffSchema(joinPointer(pointer, "items"), oldSchema.Items.A, newSchema.Items.A, direction)
+	}
+
+	if oldSchema.AdditionalProperties != nil &&
+		newSchema.AdditionalProperties != nil &&
+		oldSchema.AdditionalProperties.IsA() &&
+		newSchema.AdditionalProperties.IsA() {
+		differ.diffSchema(
+			joinPointer(pointer, "additionalProperties"),
+			oldSchema.AdditionalProperties.A,
+			newSchema.AdditionalProperties.A,
+			direction,
+		)
+	}
+
+	for _, keyword := range []string{"allOf", "anyOf", "oneOf"} {
+		var oldList, newList []*base.SchemaProxy
+
+		switch keyword {
+		case "allOf":
+			oldList, newList = oldSchema.AllOf, newSchema.AllOf
+		case "anyOf":
+			oldList, newList = oldSchema.AnyOf, newSchema.AnyOf
+		case "oneOf":
+			oldList, newList = oldSchema.OneOf, newSchema.OneOf
+		}
+
+		for i := range min(len(oldList), len(newList)) {
+			differ.diffSchema(joinPointer(pointer, keyword, fmt.Sprint(i)), oldList[i], newList[i], direction)
+		}
+	}
+}
+
+func (differ *differ) diffTypes(pointer string, oldSchema *base.Schema, newSchema *base.Schema, direction schemaDirection) {
+	if slices.Equal(oldSchema.Type, newSchema.Type) {
+		return
+	}
+
+	// A missing type allows every type.
+	removed := len(newSchema.Type) > 0 && (len(oldSchema.Type) == 0 ||
+		slices.ContainsFunc(oldSchema.Type, func(value string) bool {
+			return !slices.Contains(newSchema.Type, value)
+		}))
+	added := len(oldSchema.Type) > 0 && (len(newSchema.Type) == 0 ||
+		slices.ContainsFunc(newSchema.Type, func(value string) bool {
+			return !slices.Contains(oldSchema.Type, value)
+		}))
+
+	differ.add(Change{
+		Pointer:  joinPointer(pointer, "type"),
+		Kind:     ChangeModified,
+		Breaking: (removed && direction == schemaRequest) || (added && direction == schemaResponse),
+		Message:  fmt.Sprintf("Changed type from %v to %v", oldSchema.Type, newSchema.Type),
+		Before:   oldSchema.Type,
+		After:    newSchema.Type,
+	})
+}
+
+func (differ *differ) diffEnum(pointer string, oldSchema *base.Schema, newSchema *base.Schema, direction schemaDirection) {
+	if len(oldSchema.Enum) == 0 && len(newSchema.Enum) == 0 {
+		return
+	}
+
+	oldValues := make([]string, len(oldSchema.Enum))
+	newValues := make([]string, len(newSchema.Enum))
+
+	for i, node := range oldSchema.Enum {
+		oldValues[i] = enumValue(node)
+	}
+
+	for i, node := range newSchema.Enum {
+		newValues[i] = enumValue(node)
+	}
+
+	var removed, added []string
+
+	for _, value := range oldValues {
+		if len(newValues) > 0 && !slices.Contains(newValues, value) {
+			removed = append(removed, value)
+		}
+	}
+
+	for _, value := range newValues {
+		if len(oldValues) == 0 || !slices.Contains(oldValues, value) {
+			added = append(added, value)
+		}
+	}
+
+	enumPointer := joinPointer(pointer, "enum")
+
+	if len(removed) > 0 {
+		differ.add(Change{
+			Pointer:  enumPointer,
+			Kind:     ChangeModified,
+			Breaking: direction == schemaRequest,
+			Message:  fmt.Sprintf("Removed enum values %s", strings.Join(removed, ", ")),
+			Before:   oldValues,
+			After:    newValues,
+		})
+	}
+
+	if len(added) > 0 {
+		message := fmt.Sprintf("Added enum values %s", strings.Join(added, ", "))
+
+		if len(oldValues) == 0 {
+			message = fmt.Sprintf("Restricted values to %s", strings.Join(added, ", "))
+		}
+
+		differ.add(Change{
+			Pointer:  enumPointer,
+			Kind:     ChangeModified,
+			Breaking: (len(oldValues) == 0 && direction == schemaRequest) || (len(oldValues) > 0 && direction == schemaResponse),
+			Message:  message,
+			Before:   oldValues,
+			After:    newValues,
+		})
+	} else if len(newValues) == 0 {
+		differ.add(Change{
+			Pointer:  enumPointer,
+			Kind:     ChangeRemoved,
+			Breaking: direction == schemaResponse,
+			Message:  "Removed the enum restriction",
+			Before:   oldValues,
+		})
+	}
+}
+
+func exclusiveBound(bound *base.DynamicValue[bool, float64]) *float64 {
+	if bound != nil && bound.IsB() {
+		return &bound.B
+	}
+
+	return nil
+}
+
+func intBound(bound *int64) *float64 {
+	if bound != nil {
+		value := float64(*bound)
+
+		return &value
+	}
+
+	return nil
+}
+
+// diffBound reports changes to a bound, where tightened is true if the new bound allows fewer values.
+func (differ *differ) diffBound(
+	pointer string,
+	name string,
+	oldBound *float64,
+	newBound *float64,
+	tightened bool,
+	direction schemaDirection,
+) {
+	var before, after any
+	message := ""
+
+	switch {
+	case oldBound == nil:
+		after = *newBound
+		message = fmt.Sprintf("Added %s %v", name, *newBound)
+	case newBound == nil:
+		before = *oldBound
+		message = fmt.Sprintf("Removed %s %v", name, *oldBound)
+	default:
+		before, after = *oldBound, *newBound
+		message = fmt.Sprintf("Changed %s from %v to %v", name, *oldBound, *newBound)
+	}
+
+	differ.add(Change{
+		Pointer:  joinPointer(pointer, name),
+		Kind:     ChangeModified,
+		Breaking: tightened == (direction == schemaRequest),
+		Message:  message,
+		Before:   before,
+		After:    after,
+	})
+}
+
+func (differ *differ) diffLowerBound(
+	pointer string,
+	name string,
+	oldBound *float64,
+	newBound *float64,
+	direction schemaDirection,
+) {
+	if (oldBound == nil && newBound == nil) || (oldBound != nil && newBound != nil && *oldBound == *newBound) {
+		return
+	}
+
+	tightened := newBound != nil && (oldBound == nil || *newBound > *oldBound)
+	differ.diffBound(pointer, name, oldBound, newBound, tightened, direction)
+}
+
+func (differ *differ) diffUpperBound(
+	pointer string,
+	name string,
+	oldBound *float64,
+	newBound *float64,
+	direction schemaDirection,
+) {
+	if (oldBound == nil && newBound == nil) || (oldBound != nil && newBound != nil && *oldBound == *newBound) {
+		return
+	}
+
+	tightened := newBound != nil && (oldBound == nil || *newBound < *oldBound)
+	differ.diffBound(pointer, name, oldBound, newBound, tightened, direction)
+}
+
+func (differ *differ) diffProperties(pointer string, oldSchema *base.Schema, newSchema *base.Schema, direction schemaDirection) {
+	for name, oldProperty := range oldSchema.Properties.FromOldest() {
+		propertyPointer := joinPointer(pointer, "properties", name)
+		oldRequired := slices.Contains(oldSchema.Required, name)
+
+		if newProperty, ok := mapGet(newSchema.Properties, name); ok {
+			newRequired := slices.Contains(newSchema.Required, name)
+
+			if oldRequired != newRequired {
+				message := fmt.Sprintf("Property %q is now required", name)
+
+				if !newRequired {
+					message = fmt.Sprintf("Property %q is no longer required", name)
+				}
+
+				differ.add(Change{
+					Pointer:  joinPointer(pointer, "required"),
+					Kind:     ChangeModified,
+					Breaking: newRequired == (direction == schemaRequest),
+					Message:  message,
+					Before:   oldSchema.Required,
+					After:    newSchema.Required,
+				})
+			}
+
+			differ.diffSchema(propertyPointer, oldProperty, newProperty, direction)
+		} else {
+			message := fmt.Sprintf("Removed property %q", name)
+
+			if oldRequired {
+				message = fmt.Sprintf("Removed required property %q", name)
+			}
+
+			differ.add(Change{
+				Pointer:  propertyPointer,
+				Kind:     ChangeRemoved,
+				Breaking: direction == schemaResponse,
+				Message:  message,
+			})
+		}
+	}
+
+	for name := range newSchema.Properties.KeysFromOldest() {
+		if _, ok := mapGet(oldSchema.Properties, name); !ok {
+			required := slices.Contains(newSchema.Required, name)
+			message := fmt.Sprintf("Added property %q", name)
+
+			if required {
+				message = fmt.Sprintf("Added required property %q", name)
+			}
+
+			differ.add(Change{
+				Pointer:  joinPointer(pointer, "properties", name),
+				Kind:     ChangeAdded,
+				Breaking: required && direction == schemaRequest,
+				Message:  message,
+			})
+		}
+	}
+}
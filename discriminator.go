@@ -0,0 +1,243 @@
+package openapispecconverter
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// discriminatorMappingExtension holds the mapping of a 3.x discriminator in Swagger,
+// where a discriminator is only the name of a property.
+const discriminatorMappingExtension = "x-discriminator-mapping"
+
+// replaceSchemaReferencePrefix replaces the prefix of a reference to a schema,
+// leaving values that aren't references with the prefix as they are.
+func replaceSchemaReferencePrefix(ref string, oldPrefix string, newPrefix string) string {
+	if name, ok := strings.CutPrefix(ref, oldPrefix); ok {
+		return newPrefix + name
+	}
+
+	return ref
+}
+
+// hasAllOfReference returns true if a Swagger schema includes a reference to a definition in allOf.
+func hasAllOfReference(swaggerSchema *openapi2.Schema, ref string) bool {
+	return slices.ContainsFunc(swaggerSchema.AllOf, func(schemaRef *openapi2.SchemaRef) bool {
+		return schemaRef != nil && schemaRef.Ref == ref
+	})
+}
+
+// convertOneOfToSwaggerAllOf makes every schema in the oneOf of a 3.x schema with a discriminator
+// inherit from the Swagger definition of the schema with allOf, as Swagger has no oneOf.
+func convertOneOfToSwaggerAllOf(
+	pointer string,
+	name string,
+	schema *openapi3.Schema,
+	swaggerDoc *openapi2.T,
+	report *Report,
+) {
+	ref := "#/definitions/" + name
+	var subtypes []string
+	var removed []any
+
+	for _, oneOf := range schema.OneOf {
+		if oneOf == nil {
+			continue
+		}
+
+		subtype, ok := strings.CutPrefix(oneOf.Ref, "#/components/schemas/")
+		swaggerSubtype := swaggerDoc.Definitions[subtype]
+
+		if !ok || subtype == name || swaggerSubtype == nil || swaggerSubtype.Ref != "" || swaggerSubtype.Value == nil {
+			if len(oneOf.Ref) > 0 {
+				removed = append(removed, oneOf.Ref)
+			} else {
+				removed = append(removed, oneOf.Value)
+			}
+
+			continue
+		}
+
+		subtypes = append(subtypes, subtype)
+
+		if hasAllOfReference(swaggerSubtype.Value, ref) {
+			continue
+		}
+
+		swaggerDoc.Definitions[subtype] = &openapi2.SchemaRef{
+			Value: &openapi2.Schema{
+				AllOf: openapi2.SchemaRefs{{Ref: ref}, swaggerSubtype},
+			},
+		}
+	}
+
+	if len(subtypes) > 0 {
+		report.Add(Diagnostic{
+			Pointer:  joinPointer(pointer, "oneOf"),
+			Rule:     "one-of-to-all-of",
+			Severity: SeverityInfo,
+			Message:  "Replaced oneOf with subtypes that inherit from the schema with allOf, as Swagger has no oneOf",
+			Before:   subtypes,
+			After:    map[string]any{"allOf": []map[string]any{{"$ref": ref}}},
+		})
+	}
+
+	if len(removed) > 0 {
+		report.Add(Diagnostic{
+			Pointer:  joinPointer(pointer, "oneOf"),
+			Rule:     "one-of-removed",
+			Severity: SeverityWarning,
+			Message:  "Removed schemas in oneOf that are not references to other schemas, as they can't inherit from the schema",
+			Before:   removed,
+		})
+	}
+}
+
+// requireSwaggerDiscriminator makes sure the discriminator of a Swagger definition is a required property,
+// as Swagger demands, so every subtype inheriting from the definition requires it too.
+func requireSwaggerDiscriminator(pointer string, swaggerSchema *openapi2.Schema, report *Report) {
+	propertyName := swaggerSchema.Discriminator
+
+	if swaggerSchema.Properties[propertyName] == nil {
+		if swaggerSchema.Properties == nil {
+			swaggerSchema.Properties = openapi2.Schemas{}
+		}
+
+		if swaggerSchema.Type == nil {
+			swaggerSchema.Type = &openapi3.Types{"object"}
+		}
+
+		swaggerSchema.Properties[propertyName] = &openapi2.SchemaRef{
+			Value: &openapi2.Schema{Type: &openapi3.Types{"string"}},
+		}
+
+		report.Add(Diagnostic{
+			Pointer:  joinPointer(pointer, "propertyName"),
+			Rule:     "discriminator-property-added",
+			Severity: SeverityInfo,
+			Message:  "Added a string property for the discriminator, as Swagger requires the property in the schema",
+			After:    map[string]any{"properties": map[string]any{propertyName: map[string]any{"type": "string"}}},
+		})
+	}
+
+	if !slices.Contains(swaggerSchema.Required, propertyName) {
+		// The slice is shared with the 3.0 schema, so it must be copied.
+		swaggerSchema.Required = append(slices.Clone(swaggerSchema.Required), propertyName)
+
+		report.Add(Diagnostic{
+			Pointer:  joinPointer(pointer, "propertyName"),
+			Rule:     "discriminator-required",
+			Severity: SeverityWarning,
+			Message:  "Made the discriminator property required, as Swagger requires it in every subtype",
+			Before:   propertyName,
+			After:    map[string]any{"required": swaggerSchema.Required},
+		})
+	}
+}
+
+// convertDiscriminatorsToSwagger sets the discriminators of Swagger definitions from 3.x component schemas,
+// which kin-openapi drops.
+//
+// Mappings are kept in an extension, and oneOf is replaced with allOf inheritance.
+func convertDiscriminatorsToSwagger(doc *openapi3.T, swaggerDoc *openapi2.T, report *Report) {
+	if doc.Components == nil {
+		return
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(doc.Components.Schemas)) {
+		schemaRef := doc.Components.Schemas[name]
+		swaggerSchemaRef := swaggerDoc.Definitions[name]
+
+		if schemaRef == nil || schemaRef.Ref != "" || schemaRef.Value == nil || schemaRef.Value.Discriminator == nil ||
+			swaggerSchemaRef == nil || swaggerSchemaRef.Ref != "" || swaggerSchemaRef.Value == nil {
+			continue
+		}
+
+		schema := schemaRef.Value
+		swaggerSchema := swaggerSchemaRef.Value
+		pointer := joinPointer("", "components", "schemas", name)
+		discriminator := schema.Discriminator
+		swaggerSchema.Discriminator = discriminator.PropertyName
+
+		if len(discriminator.Mapping) > 0 {
+			mapping := map[string]any{}
+
+			for value, ref := range discriminator.Mapping {
+				mapping[value] = replaceSchemaReferencePrefix(ref, "#/components/schemas/", "#/definitions/")
+			}
+
+			// The extensions are shared with the 3.0 schema, so they must be copied.
+			swaggerSchema.Extensions = maps.Clone(swaggerSchema.Extensions)
+
+			if swaggerSchema.Extensions == nil {
+				swaggerSchema.Extensions = map[string]any{}
+			}
+
+			swaggerSchema.Extensions[discriminatorMappingExtension] = mapping
+
+			report.Add(Diagnostic{
+				Pointer:  joinPointer(pointer, "discriminator", "mapping"),
+				Rule:     "discriminator-mapping-to-extension",
+				Severity: SeverityInfo,
+				Message:  "Moved the discriminator mapping to " + discriminatorMappingExtension + ", as Swagger has no mappings",
+				Before:   map[string]string(discriminator.Mapping),
+				After:    mapping,
+			})
+		}
+
+		if len(schema.OneOf) > 0 {
+			convertOneOfToSwaggerAllOf(pointer, name, schema, swaggerDoc, report)
+		}
+
+		requireSwaggerDiscriminator(joinPointer(pointer, "discriminator"), swaggerSchema, report)
+	}
+}
+
+// convertSwaggerDiscriminators sets the mapping of discriminators from the x-discriminator-mapping extension
+// of Swagger definitions.
+func convertSwaggerDiscriminators(swaggerDoc *openapi2.T, doc *openapi3.T, report *Report) {
+	if doc.Components == nil {
+		return
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(swaggerDoc.Definitions)) {
+		schemaRef := doc.Components.Schemas[name]
+
+		if schemaRef == nil || schemaRef.Ref != "" || schemaRef.Value == nil || schemaRef.Value.Discriminator == nil {
+			continue
+		}
+
+		schema := schemaRef.Value
+		extension, ok := schema.Extensions[discriminatorMappingExtension].(map[string]any)
+
+		if !ok {
+			continue
+		}
+
+		mapping := openapi3.StringMap{}
+
+		for value, ref := range extension {
+			mapping[value] = replaceSchemaReferencePrefix(fmt.Sprint(ref), "#/definitions/", "#/components/schemas/")
+		}
+
+		schema.Discriminator.Mapping = mapping
+		delete(schema.Extensions, discriminatorMappingExtension)
+
+		if len(schema.Extensions) == 0 {
+			schema.Extensions = nil
+		}
+
+		report.Add(Diagnostic{
+			Pointer:  joinPointer("", "definitions", name, discriminatorMappingExtension),
+			Rule:     "x-discriminator-mapping-to-mapping",
+			Severity: SeverityInfo,
+			Message:  "Replaced " + discriminatorMappingExtension + " with the mapping of the discriminator",
+			Before:   extension,
+			After:    map[string]string(mapping),
+		})
+	}
+}
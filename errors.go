@@ -0,0 +1,90 @@
+package openapispecconverter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseError is returned when input data cannot be parsed as a Swagger or OpenAPI document.
+type ParseError struct {
+	Err error
+}
+
+func (err *ParseError) Error() string {
+	return fmt.Sprintf("Cannot parse Swagger or OpenAPI document: %v", err.Err)
+}
+
+func (err *ParseError) Unwrap() error {
+	return err.Err
+}
+
+// UnsupportedVersionError is returned when a document declares a version we cannot convert.
+type UnsupportedVersionError struct {
+	Version string
+	// Pointer is a JSON pointer to the version field, which is empty if the document has no version field.
+	Pointer string
+	// Line is the line of the version field in the document.
+	Line int
+}
+
+func (err *UnsupportedVersionError) Error() string {
+	if len(err.Pointer) == 0 {
+		return "Unsupported input document: no openapi or swagger version field found"
+	}
+
+	return fmt.Sprintf("Unsupported input document OpenAPI version %q at %s, line %d", err.Version, err.Pointer, err.Line)
+}
+
+// ConversionError is returned when a single step between two versions fails.
+type ConversionError struct {
+	From SpecVersion
+	To   SpecVersion
+	Err  error
+}
+
+func (err *ConversionError) Error() string {
+	return fmt.Sprintf("Cannot convert %s to %s: %v", err.From, err.To, err.Err)
+}
+
+func (err *ConversionError) Unwrap() error {
+	return err.Err
+}
+
+// FormatError is returned when converted data cannot be rendered in the requested output format.
+type FormatError struct {
+	Format Format
+	Err    error
+}
+
+func (err *FormatError) Error() string {
+	return fmt.Sprintf("Error converting to %s: %v", err.Format, err.Err)
+}
+
+func (err *FormatError) Unwrap() error {
+	return err.Err
+}
+
+// ReferenceError is returned when a `$ref` to another file cannot be resolved.
+type ReferenceError struct {
+	Ref  string
+	File string
+	Err  error
+}
+
+func (err *ReferenceError) Error() string {
+	return fmt.Sprintf("Cannot resolve $ref %s in %s: %v", err.Ref, err.File, err.Err)
+}
+
+func (err *ReferenceError) Unwrap() error {
+	return err.Err
+}
+
+// CircularReferenceError is returned when references form a cycle that cannot be inlined.
+type CircularReferenceError struct {
+	// Cycle lists each reference in the cycle, ending with the first reference again.
+	Cycle []string
+}
+
+func (err *CircularReferenceError) Error() string {
+	return fmt.Sprintf("Circular reference: %s", strings.Join(err.Cycle, " -> "))
+}
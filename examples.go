@@ -0,0 +1,470 @@
+package openapispecconverter
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pb33f/libopenapi"
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/libopenapi/orderedmap"
+	"gopkg.in/yaml.v3"
+)
+
+// generatedExampleName is the name of examples added to media types by AddExamples.
+const generatedExampleName = "generated"
+
+// stringFormatExamples are example values for string formats.
+var stringFormatExamples = map[string]string{
+	"date-time":     "2024-01-01T00:00:00Z",
+	"date":          "2024-01-01",
+	"time":          "00:00:00Z",
+	"duration":      "P1D",
+	"email":         "user@example.com",
+	"idn-email":     "user@example.com",
+	"hostname":      "example.com",
+	"idn-hostname":  "example.com",
+	"ipv4":          "192.0.2.1",
+	"ipv6":          "2001:db8::1",
+	"uri":           "https://example.com",
+	"uri-reference": "https://example.com",
+	"iri":           "https://example.com",
+	"iri-reference": "https://example.com",
+	"url":           "https://example.com",
+	"uuid":          "3fa85f64-5717-4562-b3fc-2c963f66afa6",
+	"byte":          "ZXhhbXBsZQ==",
+	"binary":        "",
+	"password":      "password",
+	"regex":         ".*",
+}
+
+// exampleGenerator builds example values for schemas.
+type exampleGenerator struct {
+	// request leaves out readOnly properties when set, and writeOnly properties otherwise.
+	request bool
+	// references lists the references examples are being built for, so recursive schemas stop.
+	references []string
+}
+
+// scalarNode creates a YAML scalar node.
+func scalarNode(tag string, value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value}
+}
+
+// exampleValue decodes the value of a generated example, or nil if there isn't one.
+func exampleValue(node *yaml.Node) any {
+	if node == nil {
+		return nil
+	}
+
+	return nodeValue(node)
+}
+
+// schema returns an example value for a schema, or nil if no example can be built.
+//
+// Values set in the schema with `examples`, `example`, `default`, `const`, or `enum` are used first,
+// and other values are built from the type and format of the schema.
+func (generator exampleGenerator) schema(proxy *base.SchemaProxy) *yaml.Node {
+	if proxy == nil {
+		return nil
+	}
+
+	if proxy.IsReference() {
+		if slices.Contains(generator.references, proxy.GetReference()) {
+			return nil
+		}
+
+		generator.references = append(slices.Clip(generator.references), proxy.GetReference())
+	}
+
+	schema := proxy.Schema()
+
+	if schema == nil {
+		return nil
+	}
+
+	switch {
+	case len(schema.Examples) > 0:
+		return copyNode(schema.Examples[0])
+	case schema.Example != nil:
+		return copyNode(schema.Example)
+	case schema.Default != nil:
+		return copyNode(schema.Default)
+	case schema.Const != nil:
+		return copyNode(schema.Const)
+	case len(schema.Enum) > 0:
+		return copyNode(schema.Enum[0])
+	}
+
+	if len(schema.AllOf) > 0 {
+		return generator.allOf(schema.AllOf)
+	}
+
+	for _, schemaList := range [][]*base.SchemaProxy{schema.OneOf, schema.AnyOf} {
+		for _, option := range schemaList {
+			if example := generator.schema(option); example != nil {
+				return example
+			}
+		}
+	}
+
+	schemaType := ""
+
+	for _, typeName := range schema.Type {
+		if typeName != "null" {
+			schemaType = typeName
+
+			break
+		}
+	}
+
+	if len(schemaType) == 0 && schema.Properties != nil {
+		schemaType = "object"
+	}
+
+	switch schemaType {
+	case "object":
+		return generator.object(schema)
+	case "array":
+		return generator.array(schema)
+	case "string":
+		return generator.string(schema)
+	case "integer", "number":
+		return generator.number(schema, schemaType == "integer")
+	case "boolean":
+		return scalarNode("!!bool", "true")
+	case "null":
+		return scalarNode("!!null", "null")
+	default:
+		if len(schema.Type) > 0 {
+			return scalarNode("!!null", "null")
+		}
+
+		return nil
+	}
+}
+
+// allOf merges the examples for every schema in `allOf`.
+func (generator exampleGenerator) allOf(allOf []*base.SchemaProxy) *yaml.Node {
+	object := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+
+	for _, proxy := range allOf {
+		example := generator.schema(proxy)
+
+		if example == nil {
+			continue
+		}
+
+		// Examples which aren't objects can't be merged, so the first one is used.
+		if example.Kind != yaml.MappingNode {
+			return example
+		}
+
+		for i := 0; i+1 < len(example.Content); i += 2 {
+			setMappingValue(object, example.Content[i].Value, example.Content[i+1])
+		}
+	}
+
+	return object
+}
+
+func (generator exampleGenerator) object(schema *base.Schema) *yaml.Node {
+	object := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+
+	for name, property := range schema.Properties.FromOldest() {
+		if propertySchema := property.Schema(); propertySchema != nil {
+			// Properties are only sent in one direction if they are readOnly or writeOnly.
+			if generator.request && propertySchema.ReadOnly != nil && *propertySchema.ReadOnly {
+				continue
+			}
+
+			if !generator.request && propertySchema.WriteOnly != nil && *propertySchema.WriteOnly {
+				continue
+			}
+		}
+
+		if example := generator.schema(property); example != nil {
+			setMappingValue(object, name, example)
+		}
+	}
+
+	if len(object.Content) == 0 && schema.AdditionalProperties != nil && schema.AdditionalProperties.IsA() {
+		if example := generator.schema(schema.AdditionalProperties.A); example != nil {
+			setMappingValue(object, "key", example)
+		}
+	}
+
+	return object
+}
+
+func (generator exampleGenerator) array(schema *base.Schema) *yaml.Node {
+	array := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+
+	for _, item := range schema.PrefixItems {
+		if example := generator.schema(item); example != nil {
+			array.Content = append(array.Content, example)
+		}
+	}
+
+	if len(array.Content) == 0 && schema.Items != nil && schema.Items.IsA() {
+		if example := generator.schema(schema.Items.A); example != nil {
+			array.Content = append(array.Content, example)
+		}
+	}
+
+	return array
+}
+
+func (generator exampleGenerator) string(schema *base.Schema) *yaml.Node {
+	value, ok := stringFormatExamples[schema.Format]
+
+	if !ok {
+		value = "string"
+
+		if schema.MinLength != nil && int(*schema.MinLength) > len(value) {
+			value = strings.Repeat("a", int(*schema.MinLength))
+		}
+
+		if schema.MaxLength != nil && int(*schema.MaxLength) < len(value) {
+			value = value[:*schema.MaxLength]
+		}
+	}
+
+	return scalarNode("!!str", value)
+}
+
+func (generator exampleGenerator) number(schema *base.Schema, integer bool) *yaml.Node {
+	var lower, upper float64
+	var hasLower, hasUpper, lowerExclusive, upperExclusive bool
+
+	if schema.Minimum != nil {
+		lower, hasLower = *schema.Minimum, true
+	}
+
+	if schema.ExclusiveMinimum != nil && schema.ExclusiveMinimum.IsB() && (!hasLower || schema.ExclusiveMinimum.B >= lower) {
+		lower, hasLower, lowerExclusive = schema.ExclusiveMinimum.B, true, true
+	}
+
+	if schema.Maximum != nil {
+		upper, hasUpper = *schema.Maximum, true
+	}
+
+	if schema.ExclusiveMaximum != nil && schema.ExclusiveMaximum.IsB() && (!hasUpper || schema.ExclusiveMaximum.B <= upper) {
+		upper, hasUpper, upperExclusive = schema.ExclusiveMaximum.B, true, true
+	}
+
+	value := 0.0
+
+	// Use the lowest value allowed, or the highest if it's below 0.
+	switch {
+	case hasLower && integer && lowerExclusive:
+		value = math.Floor(lower) + 1
+	case hasLower && integer:
+		value = math.Ceil(lower)
+	case hasLower && lowerExclusive:
+		value = lower + 1
+	case hasLower:
+		value = lower
+	case hasUpper && upper <= 0 && integer && upperExclusive:
+		value = math.Ceil(upper) - 1
+	case hasUpper && upper <= 0 && integer:
+		value = math.Floor(upper)
+	case hasUpper && upper <= 0 && upperExclusive:
+		value = upper - 1
+	case hasUpper && upper <= 0:
+		value = upper
+	}
+
+	// Stepping over an exclusive minimum can pass the maximum, so the middle of the range is used instead.
+	if hasLower && hasUpper && (value > upper || upperExclusive && value >= upper) {
+		value = (lower + upper) / 2
+
+		// Use a whole number in the range for integers, if there is one.
+		if integer {
+			for _, whole := range []float64{math.Floor(value), math.Ceil(value)} {
+				if (whole > lower || !lowerExclusive && whole == lower) && (whole < upper || !upperExclusive && whole == upper) {
+					value = whole
+
+					break
+				}
+			}
+		}
+	}
+
+	if value == math.Trunc(value) {
+		return scalarNode("!!int", strconv.FormatFloat(value, 'f', 0, 64))
+	}
+
+	return scalarNode("!!float", strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+// mediaType returns an example value for a media type, preferring examples set for the media type.
+func (generator exampleGenerator) mediaType(mediaType *v3.MediaType) *yaml.Node {
+	if mediaType.Example != nil {
+		return copyNode(mediaType.Example)
+	}
+
+	if mediaType.Examples != nil {
+		for _, example := range mediaType.Examples.FromOldest() {
+			if example != nil && example.Value != nil {
+				return copyNode(example.Value)
+			}
+		}
+	}
+
+	return generator.schema(mediaType.Schema)
+}
+
+// loadExamplesDocument converts a document to 3.1, and loads it for generating examples.
+func loadExamplesDocument(data []byte, report *Report) (libopenapi.Document, *libopenapi.DocumentModel[v3.Document], error) {
+	data, err := convertDocument(data, OpenAPI31, report, conversionOptions{})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	doc, err := libopenapi.NewDocument(data)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error loading document: %w", err)
+	}
+
+	model, errs := doc.BuildV3Model()
+
+	if len(errs) > 0 {
+		return nil, nil, fmt.Errorf("Errors loading document: %w", errors.Join(errs...))
+	}
+
+	return doc, model, nil
+}
+
+// operationName returns a name for an operation which can be used in filenames.
+func operationName(path string, method string, operation *v3.Operation) string {
+	if len(operation.OperationId) > 0 {
+		return componentName(operation.OperationId)
+	}
+
+	path = strings.NewReplacer("{", "", "}", "", "/", "-").Replace(strings.Trim(path, "/"))
+
+	return componentName(strings.TrimSuffix(method+"-"+path, "-"))
+}
+
+// GenerateExamples converts a document of any version to 3.1, and generates an example
+// request and response body for every media type of every operation.
+//
+// The files to write are returned by filename, named after the operation, the response status, and the media type,
+// such as `createPet.request.application_json.json` or `getPet.response.200.application_json.json`.
+func GenerateExamples(data []byte, report *Report) (map[string][]byte, error) {
+	_, model, err := loadExamplesDocument(data, report)
+
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string][]byte{}
+
+	addContent := func(name string, content *orderedmap.Map[string, *v3.MediaType], request bool) error {
+		for contentType, mediaType := range content.FromOldest() {
+			if mediaType == nil {
+				continue
+			}
+
+			example := exampleGenerator{request: request}.mediaType(mediaType)
+
+			if example == nil {
+				continue
+			}
+
+			filename := name + "." + componentName(contentType) + ".json"
+
+			if files[filename], err = renderJSONFile(example); err != nil {
+				return fmt.Errorf("Error rendering example %s: %w", filename, err)
+			}
+		}
+
+		return nil
+	}
+
+	if model.Model.Paths == nil {
+		return files, nil
+	}
+
+	for path, pathItem := range model.Model.Paths.PathItems.FromOldest() {
+		for method, operation := range pathItem.GetOperations().FromOldest() {
+			name := operationName(path, method, operation)
+
+			if operation.RequestBody != nil {
+				if err := addContent(name+".request", operation.RequestBody.Content, true); err != nil {
+					return nil, err
+				}
+			}
+
+			if operation.Responses == nil {
+				continue
+			}
+
+			for code, response := range operation.Responses.Codes.FromOldest() {
+				if response != nil {
+					if err := addContent(name+".response."+componentName(code), response.Content, false); err != nil {
+						return nil, err
+					}
+				}
+			}
+
+			if response := operation.Responses.Default; response != nil {
+				if err := addContent(name+".response.default", response.Content, false); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	return files, nil
+}
+
+// AddExamples converts a document of any version to 3.1, and adds a generated example to
+// every media type of request bodies and responses which has no `example` or `examples`.
+//
+// Examples are added to `examples` with the name "generated". The result can be converted to
+// any version with a Converter.
+func AddExamples(data []byte, report *Report) ([]byte, error) {
+	doc, model, err := loadExamplesDocument(data, report)
+
+	if err != nil {
+		return nil, err
+	}
+
+	updateAllContent(model, func(content *orderedmap.Map[string, *v3.MediaType], pointer string, request bool) {
+		for contentType, mediaType := range content.FromOldest() {
+			if mediaType == nil || mediaType.Example != nil || (mediaType.Examples != nil && mediaType.Examples.Len() > 0) {
+				continue
+			}
+
+			example := exampleGenerator{request: request}.mediaType(mediaType)
+
+			if example == nil {
+				continue
+			}
+
+			mediaType.Examples = orderedmap.New[string, *base.Example]()
+			mediaType.Examples.Set(generatedExampleName, &base.Example{
+				Summary: "Generated example",
+				Value:   example,
+			})
+
+			report.Add(Diagnostic{
+				Pointer:  joinPointer(pointer, contentType, "examples", generatedExampleName),
+				Rule:     "example-generated",
+				Severity: SeverityInfo,
+				Message:  "Added an example generated from the schema",
+				After:    nodeValue(example),
+			})
+		}
+	})
+
+	return renderDocument(doc, model)
+}
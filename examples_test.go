@@ -0,0 +1,49 @@
+package openapispecconverter
+
+import (
+	"testing"
+
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+)
+
+func TestExampleNumberBounds(t *testing.T) {
+	bound := func(value float64) *float64 {
+		return &value
+	}
+	exclusive := func(value float64) *base.DynamicValue[bool, float64] {
+		return &base.DynamicValue[bool, float64]{N: 1, B: value}
+	}
+
+	tests := []struct {
+		name    string
+		schema  *base.Schema
+		integer bool
+		value   string
+	}{
+		{"no bounds", &base.Schema{}, false, "0"},
+		{"minimum", &base.Schema{Minimum: bound(5)}, false, "5"},
+		{"exclusive minimum", &base.Schema{ExclusiveMinimum: exclusive(5)}, false, "6"},
+		{"negative maximum", &base.Schema{Maximum: bound(-5)}, false, "-5"},
+		{"negative exclusive maximum", &base.Schema{ExclusiveMaximum: exclusive(-5)}, false, "-6"},
+		{"positive maximum", &base.Schema{Maximum: bound(5)}, false, "0"},
+		{"inclusive range", &base.Schema{Minimum: bound(1), Maximum: bound(2)}, false, "1"},
+		{"exclusive range", &base.Schema{ExclusiveMinimum: exclusive(0), ExclusiveMaximum: exclusive(1)}, false, "0.5"},
+		{"exclusive minimum and maximum", &base.Schema{ExclusiveMinimum: exclusive(0), Maximum: bound(1)}, false, "1"},
+		{"fractional range", &base.Schema{ExclusiveMinimum: exclusive(0.5), Maximum: bound(1)}, false, "0.75"},
+		{"integer fractional minimum", &base.Schema{Minimum: bound(1.5)}, true, "2"},
+		{"integer exclusive range", &base.Schema{ExclusiveMinimum: exclusive(0), ExclusiveMaximum: exclusive(3)}, true, "1"},
+		{"integer fractional range", &base.Schema{ExclusiveMinimum: exclusive(0.5), ExclusiveMaximum: exclusive(1.5)}, true, "1"},
+		{"integer negative exclusive maximum", &base.Schema{ExclusiveMaximum: exclusive(-0.5)}, true, "-1"},
+		{"tighter exclusive minimum", &base.Schema{Minimum: bound(0), ExclusiveMinimum: exclusive(10), Maximum: bound(10.5)}, false, "10.25"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			node := exampleGenerator{}.number(test.schema, test.integer)
+
+			if node.Value != test.value {
+				t.Errorf("Expected %s, got %s", test.value, node.Value)
+			}
+		})
+	}
+}
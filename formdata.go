@@ -0,0 +1,612 @@
+package openapispecconverter
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi2conv"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// formDataNameExtension is set by kin-openapi on form properties converted from formData parameters.
+const formDataNameExtension = "x-formData-name"
+
+// isBinarySchema returns true for schemas of binary strings, which are files in Swagger.
+func isBinarySchema(schemaRef *openapi3.SchemaRef) bool {
+	return schemaRef != nil && schemaRef.Value != nil && schemaRef.Value.Type.Is("string") &&
+		schemaRef.Value.Format == "binary"
+}
+
+// binarySwaggerSchema returns a Swagger schema for a binary string, which kin-openapi drops from schemas.
+func binarySwaggerSchema(schema *openapi3.Schema) *openapi2.SchemaRef {
+	return &openapi2.SchemaRef{
+		Value: &openapi2.Schema{
+			Type:        &openapi3.Types{"string"},
+			Format:      "binary",
+			Title:       schema.Title,
+			Description: schema.Description,
+			ReadOnly:    schema.ReadOnly,
+			WriteOnly:   schema.WriteOnly,
+			Extensions:  stripFormDataExtensions(schema.Extensions),
+		},
+	}
+}
+
+// stripFormDataExtensions returns the extensions of a schema for Swagger,
+// without the extension kin-openapi uses to record formData parameter names.
+func stripFormDataExtensions(extensions map[string]any) map[string]any {
+	var result map[string]any
+
+	for name, value := range extensions {
+		if strings.HasPrefix(name, "x-") && name != formDataNameExtension {
+			if result == nil {
+				result = map[string]any{}
+			}
+
+			result[name] = value
+		}
+	}
+
+	return result
+}
+
+// resolveSwaggerParameter returns the parameter a reference to the parameters of the document points to.
+func resolveSwaggerParameter(swaggerDoc *openapi2.T, parameter *openapi2.Parameter) *openapi2.Parameter {
+	if parameter != nil && parameter.Ref != "" {
+		return swaggerDoc.Parameters[strings.TrimPrefix(parameter.Ref, "#/parameters/")]
+	}
+
+	return parameter
+}
+
+// swaggerFormDataParameters returns the formData parameters of an operation,
+// including parameters referenced from the parameters of the document.
+func swaggerFormDataParameters(swaggerDoc *openapi2.T, parameters openapi2.Parameters) []*openapi2.Parameter {
+	var result []*openapi2.Parameter
+
+	for _, parameter := range parameters {
+		if parameter = resolveSwaggerParameter(swaggerDoc, parameter); parameter != nil && parameter.In == "formData" {
+			result = append(result, parameter)
+		}
+	}
+
+	return result
+}
+
+// moveSwaggerPathBodyParameters moves body and formData parameters of Swagger path items into each operation,
+// as kin-openapi fails to convert them for path items.
+func moveSwaggerPathBodyParameters(swaggerDoc *openapi2.T, report *Report) {
+	for _, path := range slices.Sorted(maps.Keys(swaggerDoc.Paths)) {
+		swaggerPathItem := swaggerDoc.Paths[path]
+
+		if swaggerPathItem == nil {
+			continue
+		}
+
+		var kept openapi2.Parameters
+		var moved openapi2.Parameters
+
+		for i, parameter := range swaggerPathItem.Parameters {
+			resolved := resolveSwaggerParameter(swaggerDoc, parameter)
+
+			if resolved == nil || (resolved.In != "body" && resolved.In != "formData") {
+				kept = append(kept, parameter)
+
+				continue
+			}
+
+			moved = append(moved, parameter)
+
+			report.Add(Diagnostic{
+				Pointer:  joinPointer("", "paths", path, "parameters", fmt.Sprint(i)),
+				Rule:     "path-parameter-to-operations",
+				Severity: SeverityInfo,
+				Message:  fmt.Sprintf("Moved the %s parameter %s into each operation of the path", resolved.In, resolved.Name),
+			})
+		}
+
+		if len(moved) == 0 {
+			continue
+		}
+
+		swaggerPathItem.Parameters = kept
+		swaggerOperations := swaggerPathItem.Operations()
+
+		for _, method := range slices.Sorted(maps.Keys(swaggerOperations)) {
+			swaggerOperation := swaggerOperations[method]
+
+			for _, parameter := range moved {
+				resolved := resolveSwaggerParameter(swaggerDoc, parameter)
+
+				// Parameters of an operation override parameters of the path with the same name,
+				// and an operation can only have one body.
+				overridden := slices.ContainsFunc(swaggerOperation.Parameters, func(other *openapi2.Parameter) bool {
+					other = resolveSwaggerParameter(swaggerDoc, other)
+
+					return other != nil && other.In == resolved.In && (other.In == "body" || other.Name == resolved.Name)
+				})
+
+				if !overridden {
+					swaggerOperation.Parameters = append(swaggerOperation.Parameters, parameter)
+				}
+			}
+		}
+	}
+}
+
+// convertSwaggerFormDataMediaType replaces the `*/*` media type kin-openapi uses for formData parameters
+// of operations with no `consumes`, with the form media type Swagger uses by default.
+func convertSwaggerFormDataMediaType(
+	pointer string,
+	formDataParameters []*openapi2.Parameter,
+	requestBody *openapi3.RequestBody,
+	report *Report,
+) {
+	mediaType := requestBody.Content.Get("*/*")
+
+	if mediaType == nil || len(formDataParameters) == 0 {
+		return
+	}
+
+	contentType := "application/x-www-form-urlencoded"
+
+	// Files can only be sent with multipart/form-data.
+	for _, parameter := range formDataParameters {
+		if parameter.Type != nil && parameter.Type.Is("file") {
+			contentType = "multipart/form-data"
+		}
+	}
+
+	delete(requestBody.Content, "*/*")
+	requestBody.Content[contentType] = mediaType
+
+	report.Add(Diagnostic{
+		Pointer:  joinPointer(pointer, "consumes"),
+		Rule:     "form-data-media-type",
+		Severity: SeverityInfo,
+		Message:  "Set the media type of formData parameters to " + contentType + ", as the operation has no consumes",
+		After:    map[string]any{"consumes": []string{contentType}},
+	})
+}
+
+// convertSwaggerFileEncodings sets the content type of multipart/form-data parts for Swagger file parameters.
+func convertSwaggerFileEncodings(
+	pointer string,
+	formDataParameters []*openapi2.Parameter,
+	requestBody *openapi3.RequestBody,
+	report *Report,
+) {
+	mediaType := requestBody.Content.Get("multipart/form-data")
+
+	if mediaType == nil {
+		return
+	}
+
+	for _, parameter := range formDataParameters {
+		if parameter.Type == nil || !parameter.Type.Is("file") {
+			continue
+		}
+
+		encoding := mediaType.Encoding[parameter.Name]
+
+		if encoding == nil {
+			encoding = &openapi3.Encoding{}
+		}
+
+		if len(encoding.ContentType) > 0 {
+			continue
+		}
+
+		encoding.ContentType = "application/octet-stream"
+
+		if mediaType.Encoding == nil {
+			mediaType.Encoding = map[string]*openapi3.Encoding{}
+		}
+
+		mediaType.Encoding[parameter.Name] = encoding
+
+		report.Add(Diagnostic{
+			Pointer:  joinPointer(pointer, "requestBody", "content", "multipart/form-data", "encoding", parameter.Name),
+			Rule:     "form-file-content-type",
+			Severity: SeverityInfo,
+			Message:  "Set the content type of file parameter " + parameter.Name + " to application/octet-stream",
+			Before:   map[string]any{"in": "formData", "type": "file"},
+			After:    map[string]any{"contentType": encoding.ContentType},
+		})
+	}
+}
+
+// convertSwaggerBinaryBody sets a binary string schema for the content of Swagger body parameters with no schema,
+// which kin-openapi converts to a request body with no content.
+func convertSwaggerBinaryBody(
+	swaggerDoc *openapi2.T,
+	pointer string,
+	swaggerParameters openapi2.Parameters,
+	consumes []string,
+	requestBody *openapi3.RequestBody,
+	report *Report,
+) {
+	if len(requestBody.Content) > 0 {
+		return
+	}
+
+	for i, swaggerParameter := range swaggerParameters {
+		if swaggerParameter = resolveSwaggerParameter(swaggerDoc, swaggerParameter); swaggerParameter == nil ||
+			swaggerParameter.In != "body" || swaggerParameter.Schema != nil {
+			continue
+		}
+
+		if len(consumes) == 0 {
+			consumes = []string{"application/octet-stream"}
+		}
+
+		requestBody.Content = openapi3.Content{}
+
+		for _, contentType := range consumes {
+			requestBody.Content[contentType] = &openapi3.MediaType{
+				Schema: &openapi3.SchemaRef{Value: &openapi3.Schema{Type: &openapi3.Types{"string"}, Format: "binary"}},
+			}
+		}
+
+		report.Add(Diagnostic{
+			Pointer:  joinPointer(pointer, "parameters", fmt.Sprint(i), "schema"),
+			Rule:     "body-schema-binary",
+			Severity: SeverityInfo,
+			Message:  "Set a binary string schema for a body parameter with no schema",
+			After:    map[string]any{"type": "string", "format": "binary"},
+		})
+	}
+}
+
+// convertSwaggerFormData fixes request bodies kin-openapi creates for formData parameters,
+// and body parameters with no schema, such as binary uploads.
+func convertSwaggerFormData(swaggerDoc *openapi2.T, doc *openapi3.T, report *Report) {
+	for _, path := range slices.Sorted(maps.Keys(swaggerDoc.Paths)) {
+		swaggerPathItem := swaggerDoc.Paths[path]
+		pathItem := doc.Paths.Value(path)
+
+		if swaggerPathItem == nil || pathItem == nil {
+			continue
+		}
+
+		operations := pathItem.Operations()
+		swaggerOperations := swaggerPathItem.Operations()
+
+		for _, method := range slices.Sorted(maps.Keys(swaggerOperations)) {
+			operation := operations[method]
+
+			if operation == nil || operation.RequestBody == nil || operation.RequestBody.Ref != "" ||
+				operation.RequestBody.Value == nil {
+				continue
+			}
+
+			swaggerOperation := swaggerOperations[method]
+			operationPointer := joinPointer("", "paths", path, strings.ToLower(method))
+			consumes := swaggerOperation.Consumes
+
+			if len(consumes) == 0 {
+				consumes = swaggerDoc.Consumes
+			}
+
+			formDataParameters := swaggerFormDataParameters(swaggerDoc, swaggerOperation.Parameters)
+
+			convertSwaggerFormDataMediaType(operationPointer, formDataParameters, operation.RequestBody.Value, report)
+			convertSwaggerFileEncodings(operationPointer, formDataParameters, operation.RequestBody.Value, report)
+			convertSwaggerBinaryBody(
+				swaggerDoc,
+				operationPointer,
+				swaggerOperation.Parameters,
+				consumes,
+				operation.RequestBody.Value,
+				report,
+			)
+		}
+	}
+}
+
+// formDataParameter creates a Swagger formData parameter for a property of a form request body.
+func formDataParameter(
+	pointer string,
+	name string,
+	schemaRef *openapi3.SchemaRef,
+	required bool,
+	components *openapi3.Components,
+	report *Report,
+) *openapi2.Parameter {
+	schema := schemaRef.Value
+
+	if schema == nil {
+		return nil
+	}
+
+	parameter := &openapi2.Parameter{
+		In:              "formData",
+		Name:            name,
+		Description:     schema.Description,
+		Required:        required,
+		Type:            schema.Type,
+		Format:          schema.Format,
+		Enum:            schema.Enum,
+		Default:         schema.Default,
+		Minimum:         schema.Min,
+		Maximum:         schema.Max,
+		ExclusiveMin:    schema.ExclusiveMin,
+		ExclusiveMax:    schema.ExclusiveMax,
+		MultipleOf:      schema.MultipleOf,
+		MinLength:       schema.MinLength,
+		MaxLength:       schema.MaxLength,
+		Pattern:         schema.Pattern,
+		MinItems:        schema.MinItems,
+		MaxItems:        schema.MaxItems,
+		UniqueItems:     schema.UniqueItems,
+		AllowEmptyValue: schema.AllowEmptyValue,
+		Extensions:      stripFormDataExtensions(schema.Extensions),
+	}
+
+	if isBinarySchema(schemaRef) {
+		parameter.Type = &openapi3.Types{"file"}
+		parameter.Format = ""
+	}
+
+	if schema.Nullable {
+		if parameter.Extensions == nil {
+			parameter.Extensions = map[string]any{}
+		}
+
+		parameter.Extensions[nullableExtensions[0]] = true
+	}
+
+	if schema.Items != nil {
+		parameter.Items, _ = openapi2conv.FromV3SchemaRef(schema.Items, components)
+	}
+
+	// formData parameters can only be primitive values, arrays, or files.
+	if parameter.Type == nil || parameter.Type.Is("object") || len(parameter.Type.Slice()) != 1 {
+		parameter.Type = &openapi3.Types{"string"}
+		parameter.Format = ""
+
+		report.Add(Diagnostic{
+			Pointer:  pointer,
+			Rule:     "form-data-string",
+			Severity: SeverityWarning,
+			Message:  "Replaced the schema of " + name + " with a string, as formData parameters can't be objects",
+			After:    map[string]any{"type": "string"},
+		})
+	}
+
+	return parameter
+}
+
+// reportFormEncodingsRemoved reports encodings of form fields which Swagger can't represent.
+//
+// style and explode are converted to collectionFormat separately,
+// and application/octet-stream is what Swagger sends files as.
+func reportFormEncodingsRemoved(
+	pointer string,
+	encodings map[string]*openapi3.Encoding,
+	properties openapi3.Schemas,
+	report *Report,
+) {
+	for _, name := range slices.Sorted(maps.Keys(encodings)) {
+		encoding := encodings[name]
+
+		if encoding == nil {
+			continue
+		}
+
+		if len(encoding.ContentType) > 0 &&
+			(encoding.ContentType != "application/octet-stream" || !isBinarySchema(properties[name])) {
+			report.Add(Diagnostic{
+				Pointer:  joinPointer(pointer, name, "contentType"),
+				Rule:     "encoding-removed",
+				Severity: SeverityWarning,
+				Message:  "Removed the content type of " + name + ", as Swagger formData parameters have no content types",
+				Before:   map[string]any{"contentType": encoding.ContentType},
+			})
+		}
+
+		if len(encoding.Headers) > 0 {
+			report.Add(Diagnostic{
+				Pointer:  joinPointer(pointer, name, "headers"),
+				Rule:     "encoding-removed",
+				Severity: SeverityWarning,
+				Message:  "Removed the headers of " + name + ", as Swagger formData parameters have no headers",
+				Before:   slices.Sorted(maps.Keys(encoding.Headers)),
+			})
+		}
+	}
+}
+
+// convertFormBodyToSwagger replaces the formData parameters kin-openapi creates for a form request body.
+//
+// kin-openapi loses which properties are required, formats, and properties which are references.
+func convertFormBodyToSwagger(
+	pointer string,
+	requestBody *openapi3.RequestBodyRef,
+	swaggerOperation *openapi2.Operation,
+	components *openapi3.Components,
+	report *Report,
+) {
+	if requestBody == nil || requestBody.Ref != "" || requestBody.Value == nil {
+		return
+	}
+
+	for _, contentType := range formMediaTypes {
+		mediaType := requestBody.Value.Content.Get(contentType)
+
+		if mediaType == nil || mediaType.Schema == nil || mediaType.Schema.Value == nil {
+			continue
+		}
+
+		contentPointer := joinPointer(pointer, "requestBody", "content", contentType)
+		schema := mediaType.Schema.Value
+		parameters := slices.DeleteFunc(swaggerOperation.Parameters, func(parameter *openapi2.Parameter) bool {
+			return parameter != nil && parameter.In == "formData"
+		})
+
+		for _, name := range slices.Sorted(maps.Keys(schema.Properties)) {
+			parameter := formDataParameter(
+				joinPointer(contentPointer, "schema", "properties", name),
+				name,
+				schema.Properties[name],
+				slices.Contains(schema.Required, name),
+				components,
+				report,
+			)
+
+			if parameter != nil {
+				parameters = append(parameters, parameter)
+			}
+		}
+
+		sort.Sort(parameters)
+		swaggerOperation.Parameters = parameters
+		reportFormEncodingsRemoved(joinPointer(contentPointer, "encoding"), mediaType.Encoding, schema.Properties, report)
+
+		// Only one form media type is converted to formData parameters.
+		break
+	}
+}
+
+// restoreBinarySchemas adds binary string properties to Swagger schemas, which kin-openapi removes.
+func restoreBinarySchemas(schemaRef *openapi3.SchemaRef, swaggerSchemaRef *openapi2.SchemaRef) {
+	if schemaRef == nil || schemaRef.Ref != "" || schemaRef.Value == nil ||
+		swaggerSchemaRef == nil || swaggerSchemaRef.Ref != "" || swaggerSchemaRef.Value == nil {
+		return
+	}
+
+	schema := schemaRef.Value
+	swaggerSchema := swaggerSchemaRef.Value
+
+	for _, name := range slices.Sorted(maps.Keys(schema.Properties)) {
+		property := schema.Properties[name]
+
+		if swaggerProperty, ok := swaggerSchema.Properties[name]; ok {
+			restoreBinarySchemas(property, swaggerProperty)
+		} else if property.Ref == "" && isBinarySchema(property) {
+			if swaggerSchema.Properties == nil {
+				swaggerSchema.Properties = openapi2.Schemas{}
+			}
+
+			swaggerSchema.Properties[name] = binarySwaggerSchema(property.Value)
+		}
+	}
+
+	if swaggerSchema.Items == nil && schema.Items != nil && schema.Items.Ref == "" && isBinarySchema(schema.Items) {
+		swaggerSchema.Items = binarySwaggerSchema(schema.Items.Value)
+	} else {
+		restoreBinarySchemas(schema.Items, swaggerSchema.Items)
+	}
+
+	for i, allOf := range schema.AllOf {
+		if i < len(swaggerSchema.AllOf) {
+			restoreBinarySchemas(allOf, swaggerSchema.AllOf[i])
+		}
+	}
+}
+
+// restoreBinaryResponse sets the schema of Swagger responses with binary content to a file,
+// and restores binary properties of JSON response schemas.
+func restoreBinaryResponse(responseRef *openapi3.ResponseRef, swaggerResponse *openapi2.Response) {
+	if responseRef == nil || responseRef.Ref != "" || responseRef.Value == nil ||
+		swaggerResponse == nil || swaggerResponse.Ref != "" {
+		return
+	}
+
+	content := responseRef.Value.Content
+
+	// kin-openapi only converts the schema for application/json content.
+	if mediaType := content.Get("application/json"); mediaType != nil {
+		restoreBinarySchemas(mediaType.Schema, swaggerResponse.Schema)
+
+		return
+	}
+
+	for _, contentType := range slices.Sorted(maps.Keys(content)) {
+		if mediaType := content[contentType]; mediaType != nil && isBinarySchema(mediaType.Schema) {
+			swaggerResponse.Schema = &openapi2.SchemaRef{
+				Value: &openapi2.Schema{
+					Type:        &openapi3.Types{"file"},
+					Description: mediaType.Schema.Value.Description,
+				},
+			}
+
+			return
+		}
+	}
+}
+
+// convertFormBodiesToSwagger fixes formData parameters and binary schemas converted to Swagger,
+// which kin-openapi loses details of.
+func convertFormBodiesToSwagger(doc *openapi3.T, swaggerDoc *openapi2.T, report *Report) {
+	if doc.Components != nil {
+		for _, name := range slices.Sorted(maps.Keys(doc.Components.Schemas)) {
+			restoreBinarySchemas(doc.Components.Schemas[name], swaggerDoc.Definitions[name])
+		}
+
+		for _, name := range slices.Sorted(maps.Keys(doc.Components.Responses)) {
+			restoreBinaryResponse(doc.Components.Responses[name], swaggerDoc.Responses[name])
+		}
+	}
+
+	if doc.Paths == nil {
+		return
+	}
+
+	paths := doc.Paths.Map()
+
+	for _, path := range slices.Sorted(maps.Keys(paths)) {
+		pathItem := paths[path]
+		swaggerPathItem := swaggerDoc.Paths[path]
+
+		if pathItem == nil || swaggerPathItem == nil {
+			continue
+		}
+
+		operations := pathItem.Operations()
+		swaggerOperations := swaggerPathItem.Operations()
+
+		for _, method := range slices.Sorted(maps.Keys(operations)) {
+			operation := operations[method]
+			swaggerOperation := swaggerOperations[method]
+
+			if swaggerOperation == nil {
+				continue
+			}
+
+			convertFormBodyToSwagger(
+				joinPointer("", "paths", path, strings.ToLower(method)),
+				operation.RequestBody,
+				swaggerOperation,
+				doc.Components,
+				report,
+			)
+
+			if operation.RequestBody != nil && operation.RequestBody.Ref == "" && operation.RequestBody.Value != nil {
+				for _, parameter := range swaggerOperation.Parameters {
+					if parameter == nil || parameter.In != "body" || parameter.Ref != "" {
+						continue
+					}
+
+					for _, contentType := range slices.Sorted(maps.Keys(operation.RequestBody.Value.Content)) {
+						if mediaType := operation.RequestBody.Value.Content[contentType]; mediaType != nil {
+							restoreBinarySchemas(mediaType.Schema, parameter.Schema)
+						}
+					}
+				}
+			}
+
+			if operation.Responses == nil {
+				continue
+			}
+
+			for code, responseRef := range operation.Responses.Map() {
+				restoreBinaryResponse(responseRef, swaggerOperation.Responses[code])
+			}
+		}
+	}
+}
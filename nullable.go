@@ -0,0 +1,300 @@
+package openapispecconverter
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi3"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/libopenapi/orderedmap"
+	"gopkg.in/yaml.v3"
+)
+
+// nullableExtensions are the Swagger extensions generators use to mark a value as nullable.
+//
+// go-swagger uses x-nullable, and NSwag uses x-isnullable.
+var nullableExtensions = []string{"x-nullable", "x-isnullable"}
+
+// nullableExtensionValue returns the value of a nullable extension, and the name of the extension set.
+func nullableExtensionValue(extensions map[string]any) (bool, string, bool) {
+	for _, name := range nullableExtensions {
+		if nullable, ok := extensions[name].(bool); ok {
+			return nullable, name, true
+		}
+	}
+
+	return false, "", false
+}
+
+// convertSwaggerSchemaExtensions sets nullable for Swagger schemas with a nullable extension,
+// and copies every other extension of the schema, which kin-openapi drops.
+func convertSwaggerSchemaExtensions(
+	pointer string,
+	swaggerSchemaRef *openapi2.SchemaRef,
+	schemaRef *openapi3.SchemaRef,
+	report *Report,
+) {
+	if swaggerSchemaRef == nil || swaggerSchemaRef.Ref != "" || swaggerSchemaRef.Value == nil ||
+		schemaRef == nil || schemaRef.Ref != "" || schemaRef.Value == nil {
+		return
+	}
+
+	swaggerSchema := swaggerSchemaRef.Value
+	schema := schemaRef.Value
+
+	for _, name := range slices.Sorted(maps.Keys(swaggerSchema.Extensions)) {
+		if strings.HasPrefix(name, "x-") && !slices.Contains(nullableExtensions, name) {
+			if schema.Extensions == nil {
+				schema.Extensions = map[string]any{}
+			}
+
+			schema.Extensions[name] = swaggerSchema.Extensions[name]
+		}
+	}
+
+	if nullable, name, ok := nullableExtensionValue(swaggerSchema.Extensions); ok {
+		delete(schema.Extensions, name)
+		delete(schemaRef.Extensions, name)
+		schema.Nullable = nullable
+
+		if nullable {
+			report.Add(Diagnostic{
+				Pointer:  joinPointer(pointer, name),
+				Rule:     "x-nullable-to-nullable",
+				Severity: SeverityInfo,
+				Message:  "Replaced " + name + " with nullable",
+				Before:   map[string]any{name: true},
+				After:    map[string]any{"nullable": true},
+			})
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(swaggerSchema.Properties)) {
+		convertSwaggerSchemaExtensions(
+			joinPointer(pointer, "properties", name),
+			swaggerSchema.Properties[name],
+			schema.Properties[name],
+			report,
+		)
+	}
+
+	convertSwaggerSchemaExtensions(joinPointer(pointer, "items"), swaggerSchema.Items, schema.Items, report)
+
+	for i, swaggerAllOf := range swaggerSchema.AllOf {
+		if i < len(schema.AllOf) {
+			convertSwaggerSchemaExtensions(joinPointer(pointer, "allOf", fmt.Sprint(i)), swaggerAllOf, schema.AllOf[i], report)
+		}
+	}
+}
+
+// convertSwaggerContentExtensions converts the extensions of a Swagger schema for every media type it was copied to.
+func convertSwaggerContentExtensions(
+	pointer string,
+	swaggerSchemaRef *openapi2.SchemaRef,
+	content openapi3.Content,
+	report *Report,
+) {
+	for _, contentType := range slices.Sorted(maps.Keys(content)) {
+		if mediaType := content[contentType]; mediaType != nil {
+			convertSwaggerSchemaExtensions(pointer, swaggerSchemaRef, mediaType.Schema, report)
+			// Changes to the Swagger schema are only reported once.
+			report = nil
+		}
+	}
+}
+
+// convertSwaggerParameterNullable sets nullable on the schema of a parameter with a nullable extension.
+func convertSwaggerParameterNullable(pointer string, parameter *openapi3.Parameter, report *Report) {
+	nullable, name, ok := nullableExtensionValue(parameter.Extensions)
+
+	if !ok || parameter.Schema == nil || parameter.Schema.Ref != "" || parameter.Schema.Value == nil {
+		return
+	}
+
+	delete(parameter.Extensions, name)
+
+	if len(parameter.Extensions) == 0 {
+		parameter.Extensions = nil
+	}
+
+	parameter.Schema.Value.Nullable = nullable
+
+	if nullable {
+		report.Add(Diagnostic{
+			Pointer:  joinPointer(pointer, name),
+			Rule:     "x-nullable-to-nullable",
+			Severity: SeverityInfo,
+			Message:  "Replaced " + name + " with nullable in the schema of the parameter",
+			Before:   map[string]any{name: true},
+			After:    map[string]any{"schema": map[string]any{"nullable": true}},
+		})
+	}
+}
+
+func convertSwaggerParametersNullable(
+	pointer string,
+	swaggerParameters openapi2.Parameters,
+	parameters openapi3.Parameters,
+	requestBody *openapi3.RequestBodyRef,
+	report *Report,
+) {
+	for i, swaggerParameter := range swaggerParameters {
+		if swaggerParameter == nil || swaggerParameter.Ref != "" {
+			continue
+		}
+
+		parameterPointer := joinPointer(pointer, "parameters", fmt.Sprint(i))
+
+		if swaggerParameter.In == "body" {
+			if requestBody != nil && requestBody.Ref == "" && requestBody.Value != nil {
+				convertSwaggerContentExtensions(
+					joinPointer(parameterPointer, "schema"),
+					swaggerParameter.Schema,
+					requestBody.Value.Content,
+					report,
+				)
+			}
+		} else if parameter := findParameter(parameters, swaggerParameter.In, swaggerParameter.Name); parameter != nil {
+			convertSwaggerParameterNullable(parameterPointer, parameter, report)
+		}
+	}
+}
+
+func convertSwaggerResponsesNullable(
+	pointer string,
+	swaggerResponses map[string]*openapi2.Response,
+	responses func(string) *openapi3.ResponseRef,
+	report *Report,
+) {
+	for _, code := range slices.Sorted(maps.Keys(swaggerResponses)) {
+		swaggerResponse := swaggerResponses[code]
+		responseRef := responses(code)
+
+		if swaggerResponse == nil || swaggerResponse.Ref != "" || responseRef == nil || responseRef.Ref != "" ||
+			responseRef.Value == nil {
+			continue
+		}
+
+		convertSwaggerContentExtensions(
+			joinPointer(pointer, code, "schema"),
+			swaggerResponse.Schema,
+			responseRef.Value.Content,
+			report,
+		)
+	}
+}
+
+// convertSwaggerNullable replaces the x-nullable and x-isnullable extensions of Swagger schemas
+// and parameters with nullable, which kin-openapi only does for x-nullable in schemas.
+func convertSwaggerNullable(swaggerDoc *openapi2.T, doc *openapi3.T, report *Report) {
+	if doc.Components != nil {
+		for _, name := range slices.Sorted(maps.Keys(swaggerDoc.Definitions)) {
+			convertSwaggerSchemaExtensions(
+				joinPointer("", "definitions", name),
+				swaggerDoc.Definitions[name],
+				doc.Components.Schemas[name],
+				report,
+			)
+		}
+
+		for _, name := range slices.Sorted(maps.Keys(swaggerDoc.Parameters)) {
+			swaggerParameter := swaggerDoc.Parameters[name]
+			parameterPointer := joinPointer("", "parameters", name)
+
+			if swaggerParameter == nil || swaggerParameter.Ref != "" {
+				continue
+			}
+
+			if swaggerParameter.In == "body" {
+				if requestBodyRef := doc.Components.RequestBodies[name]; requestBodyRef != nil && requestBodyRef.Value != nil {
+					convertSwaggerContentExtensions(
+						joinPointer(parameterPointer, "schema"),
+						swaggerParameter.Schema,
+						requestBodyRef.Value.Content,
+						report,
+					)
+				}
+			} else if parameterRef := doc.Components.Parameters[name]; parameterRef != nil && parameterRef.Value != nil {
+				convertSwaggerParameterNullable(parameterPointer, parameterRef.Value, report)
+			}
+		}
+
+		convertSwaggerResponsesNullable("/responses", swaggerDoc.Responses, func(name string) *openapi3.ResponseRef {
+			return doc.Components.Responses[name]
+		}, report)
+	}
+
+	for _, path := range slices.Sorted(maps.Keys(swaggerDoc.Paths)) {
+		swaggerPathItem := swaggerDoc.Paths[path]
+		pathItem := doc.Paths.Value(path)
+
+		if swaggerPathItem == nil || pathItem == nil {
+			continue
+		}
+
+		pathPointer := joinPointer("", "paths", path)
+		convertSwaggerParametersNullable(pathPointer, swaggerPathItem.Parameters, pathItem.Parameters, nil, report)
+
+		operations := pathItem.Operations()
+		swaggerOperations := swaggerPathItem.Operations()
+
+		for _, method := range slices.Sorted(maps.Keys(swaggerOperations)) {
+			operation := operations[method]
+
+			if operation == nil {
+				continue
+			}
+
+			operationPointer := joinPointer(pathPointer, strings.ToLower(method))
+
+			convertSwaggerParametersNullable(
+				operationPointer,
+				swaggerOperations[method].Parameters,
+				operation.Parameters,
+				operation.RequestBody,
+				report,
+			)
+
+			if operation.Responses != nil {
+				convertSwaggerResponsesNullable(
+					joinPointer(operationPointer, "responses"),
+					swaggerOperations[method].Responses,
+					operation.Responses.Value,
+					report,
+				)
+			}
+		}
+	}
+}
+
+// convert30ParameterNullable sets x-nullable on parameters with a nullable schema before converting to Swagger,
+// as kin-openapi removes nullable from parameter schemas.
+func convert30ParameterNullable(parameter *v3.Parameter, pointer string, report *Report) {
+	if parameter.In == "body" || parameter.Schema == nil || parameter.Schema.IsReference() {
+		return
+	}
+
+	schema := parameter.Schema.Schema()
+
+	if schema == nil || schema.Nullable == nil || !*schema.Nullable {
+		return
+	}
+
+	if parameter.Extensions == nil {
+		parameter.Extensions = orderedmap.New[string, *yaml.Node]()
+	}
+
+	parameter.Extensions.Set(nullableExtensions[0], scalarNode("!!bool", "true"))
+
+	report.Add(Diagnostic{
+		Pointer:  joinPointer(pointer, "schema", "nullable"),
+		Rule:     "nullable-to-x-nullable",
+		Severity: SeverityInfo,
+		Message:  "Replaced nullable in the schema of the parameter with x-nullable",
+		Before:   map[string]any{"schema": map[string]any{"nullable": true}},
+		After:    map[string]any{nullableExtensions[0]: true},
+	})
+}
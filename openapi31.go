@@ -0,0 +1,354 @@
+package openapispecconverter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/pb33f/libopenapi"
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"gopkg.in/yaml.v3"
+)
+
+func clear30RequestFileContentSchemaFor31(
+	model *libopenapi.DocumentModel[v3.Document],
+	report *Report,
+) {
+	if model.Model.Paths != nil && model.Model.Paths.PathItems != nil {
+		for path, pathItem := range model.Model.Paths.PathItems.FromOldest() {
+			for method, operation := range pathItem.GetOperations().FromOldest() {
+				if operation.RequestBody != nil && operation.RequestBody.Content != nil {
+					// Clear the schema for application/octet-stream, as the type is implied.
+					if content, ok := operation.RequestBody.Content.Get("application/octet-stream"); ok {
+						report.Add(Diagnostic{
+							Pointer: joinPointer(
+								"", "paths", path, method, "requestBody", "content", "application/octet-stream", "schema",
+							),
+							Rule:     "octet-stream-schema",
+							Severity: SeverityInfo,
+							Message:  "Removed the implied schema for an application/octet-stream request body",
+						})
+						content.Schema = nil
+					}
+				}
+			}
+		}
+	}
+}
+
+func set31RequestFileContentSchemaFor30(
+	model *libopenapi.DocumentModel[v3.Document],
+	report *Report,
+) {
+	if model.Model.Paths != nil && model.Model.Paths.PathItems != nil {
+		for path, pathItem := range model.Model.Paths.PathItems.FromOldest() {
+			for method, operation := range pathItem.GetOperations().FromOldest() {
+				if operation.RequestBody != nil && operation.RequestBody.Content != nil {
+					// Clear the schema for application/octet-stream, as the type is implied.
+					if content, ok := operation.RequestBody.Content.Get("application/octet-stream"); ok {
+						report.Add(Diagnostic{
+							Pointer: joinPointer(
+								"", "paths", path, method, "requestBody", "content", "application/octet-stream", "schema",
+							),
+							Rule:     "octet-stream-schema",
+							Severity: SeverityInfo,
+							Message:  "Set a binary string schema for an application/octet-stream request body",
+						})
+						content.Schema = base.CreateSchemaProxy(&base.Schema{
+							Type:   []string{"string"},
+							Format: "binary",
+						})
+					}
+				}
+			}
+		}
+	}
+}
+
+// webhooksExtension holds webhooks in 3.0 documents, which some tools understand.
+const webhooksExtension = "x-webhooks"
+
+// renameDocumentKey renames a key at the top of a document.
+func renameDocumentKey(data []byte, from string, to string) ([]byte, error) {
+	return patchDocument(data, []string{""}, func(node *yaml.Node, pointer string) {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == from {
+				node.Content[i].Value = to
+			}
+		}
+	})
+}
+
+func convertOpenAPI30To31(data []byte, report *Report, options conversionOptions) ([]byte, error) {
+	// libopenapi doesn't load operations and schemas kept in 3.2 extensions, so they are converted on their own.
+	data, err := convertOpenAPI32ExtensionContent(data, func(data []byte, report *Report) ([]byte, error) {
+		return convertOpenAPI30DocumentTo31(data, report, options)
+	}, report)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return convertOpenAPI30DocumentTo31(data, report, options)
+}
+
+func convertOpenAPI30DocumentTo31(data []byte, report *Report, options conversionOptions) ([]byte, error) {
+	// Before loading the document, apply step 8. to load `x-webhooks` as webhooks,
+	// so their schemas are converted along with every other schema.
+	if options.webhooksExtension && bytes.Contains(data, []byte(webhooksExtension)) {
+		var err error
+
+		if data, err = promote30WebhooksExtensionTo31(data, report); err != nil {
+			return nil, err
+		}
+	}
+
+	doc, err := libopenapi.NewDocument(data)
+
+	if err != nil {
+		return nil, fmt.Errorf("Error loading document: %w", err)
+	}
+
+	model, errs := doc.BuildV3Model()
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("Errors loading document: %w", errors.Join(errs...))
+	}
+
+	// See: https://www.openapis.org/blog/2021/02/16/migrating-from-openapi-3-0-to-3-1-0
+	//
+	// The following changes need to be made.
+	//
+	// 1. Change the `openapi` version to 3.1.x.
+	// 2. Swap nullable for type arrays.
+	// 3. Replace `minimum` and `exclusiveMinimum`, and `maximum` and `exclusiveMaximum`.
+	// 4. Replace `example` with `examples` wherever we see it.
+	// 5. Modify file upload schemas.
+	// 6. Optionally replace single value `enum` with `const`.
+	// 7. Optionally replace `allOf` wrappers for a single `$ref` with `$ref`.
+	// 8. Optionally replace the `x-webhooks` extension with webhooks.
+
+	// 1. Change the `openapi` version to 3.1.x.
+	model.Model.Version = OpenAPI31.versionName()
+
+	// Before scanning all schema, apply step 5. early to clear schema for request bodies.
+	clear30RequestFileContentSchemaFor31(model, report)
+
+	contentFields := map[string]contentField{}
+
+	updateAllSchema(model, func(schema *base.Schema, pointer string) {
+		// 2. Swap nullable for type arrays.
+		convert30NullablesTo31TypeArrays(schema, pointer, report)
+		// 3. Replace `minimum` and `exclusiveMinimum`
+		convert30MinMaxTo31(schema, pointer, report)
+		// 4. Replace `example` with `examples` wherever we see it.
+		convert30ExampleTo31Examples(schema, pointer, report)
+		// 5. Modify file upload schemas.
+		if field := convert30FormatsTo31ContentFields(schema, pointer, report); field != nil {
+			contentFields[pointer] = *field
+		}
+
+		// 6. Optionally replace single value `enum` with `const`.
+		if options.enumToConst {
+			convert30SingleEnumTo31Const(schema, pointer, report)
+		}
+	})
+
+	var allOfReferences []string
+
+	// 7. Optionally replace `allOf` wrappers for a single `$ref` with `$ref`.
+	if options.collapseAllOfRefs {
+		allOfReferences = find30AllOfReferences(model)
+	}
+
+	data, err = renderDocument(doc, model)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// libopenapi doesn't render contentMediaType or contentEncoding, so we add them to the rendered document.
+	data, err = patchDocument(data, slices.Sorted(maps.Keys(contentFields)), func(schema *yaml.Node, pointer string) {
+		field := contentFields[pointer]
+		setMappingValue(schema, field.name, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: field.value})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return collapse30AllOfReferencesTo31(data, allOfReferences, report)
+}
+
+func convertOpenAPI31To30(data []byte, report *Report, options conversionOptions) ([]byte, error) {
+	// libopenapi doesn't load operations and schemas kept in 3.2 extensions, so they are converted on their own.
+	data, err := convertOpenAPI32ExtensionContent(data, func(data []byte, report *Report) ([]byte, error) {
+		return convertOpenAPI31DocumentTo30(data, report, options)
+	}, report)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return convertOpenAPI31DocumentTo30(data, report, options)
+}
+
+func convertOpenAPI31DocumentTo30(data []byte, report *Report, options conversionOptions) ([]byte, error) {
+	// Before loading the document, apply step 7. to wrap `$ref` siblings in `allOf`,
+	// so the siblings are converted along with every other schema.
+	data, err := wrap31ReferenceSiblingsIn30AllOf(data, report)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert or remove schema keywords 3.0 doesn't have before libopenapi loses them.
+	data, err = convert31SchemaKeywordsTo30(data, report)
+
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := libopenapi.NewDocument(data)
+
+	if err != nil {
+		return nil, fmt.Errorf("Error loading document: %w", err)
+	}
+
+	model, errs := doc.BuildV3Model()
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("Errors loading document: %w", errors.Join(errs...))
+	}
+
+	// We need to perform the inverse of the conversion steps in the 3.0 to 3.1 function,
+	// except `$ref` siblings are always wrapped in `allOf`, as 3.0 ignores them.
+
+	// 1. Change the `openapi` version to 3.0.x
+	model.Model.Version = OpenAPI30.versionName()
+
+	// Before scanning all schema, apply step 5. early to schema schema for file uploads where needed.
+	set31RequestFileContentSchemaFor30(model, report)
+
+	updateAllSchema(model, func(schema *base.Schema, pointer string) {
+		// 2. Swap type arrays for either `nullable` or `oneOf`
+		convert31TypeArraysTo30(schema, pointer, report)
+		// 3. Replace `minimum` and `exclusiveMinimum`, and `maximum` and `exclusiveMaximum`.
+		convert31MinMaxTo30(schema, pointer, report)
+		// 4. Replace `examples` with `example` wherever we see it.
+		convert31ExamplesTo30Example(schema, pointer, report)
+		// 5. Modify file upload schemas.
+		convert31ContentFieldsTo30Formats(schema, pointer, report)
+		// 6. Replace `const` with a single value `enum`.
+		convert31ConstTo30Enum(schema, pointer, report)
+		// Replace `prefixItems` with `items`, as 3.0 has no tuple arrays.
+		convert31PrefixItemsTo30Items(schema, pointer, report)
+	})
+
+	// Remove mutualTLS security schemes, as they are only used in 3.1.
+	remove31MutualTLSSchemes(model, report)
+
+	// We must remove additional properties only used in 3.1.
+	if len(model.Model.JsonSchemaDialect) > 0 {
+		report.Add(Diagnostic{
+			Pointer:  "/jsonSchemaDialect",
+			Rule:     "json-schema-dialect-removed",
+			Severity: SeverityWarning,
+			Message:  "Removed jsonSchemaDialect, which is not supported in 3.0",
+			Before:   model.Model.JsonSchemaDialect,
+		})
+	}
+
+	// 8. Optionally replace webhooks with the `x-webhooks` extension.
+	keepWebhooks := options.webhooksExtension && model.Model.Webhooks != nil && model.Model.Webhooks.Len() > 0
+
+	if keepWebhooks {
+		report.Add(Diagnostic{
+			Pointer:  "/webhooks",
+			Rule:     "webhooks-to-extension",
+			Severity: SeverityInfo,
+			Message:  "Moved webhooks to x-webhooks, as webhooks are not supported in 3.0",
+			Before:   slices.Collect(model.Model.Webhooks.KeysFromOldest()),
+		})
+	} else if model.Model.Webhooks != nil && model.Model.Webhooks.Len() > 0 {
+		report.Add(Diagnostic{
+			Pointer:  "/webhooks",
+			Rule:     "webhooks-removed",
+			Severity: SeverityWarning,
+			Message:  "Removed webhooks, which are not supported in 3.0",
+			Before:   slices.Collect(model.Model.Webhooks.KeysFromOldest()),
+		})
+	}
+
+	if model.Model.Info != nil && len(model.Model.Info.Summary) > 0 {
+		report.Add(Diagnostic{
+			Pointer:  "/info/summary",
+			Rule:     "info-summary-removed",
+			Severity: SeverityWarning,
+			Message:  "Removed info.summary, which is not supported in 3.0",
+			Before:   model.Model.Info.Summary,
+		})
+	}
+
+	model.Model.JsonSchemaDialect = ""
+
+	if !keepWebhooks {
+		model.Model.Webhooks = nil
+	}
+
+	if model.Model.Info != nil {
+		model.Model.Info.Summary = ""
+	}
+
+	data, err = renderDocument(doc, model)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// The webhooks are rendered with schemas already converted to 3.0, and only need to be renamed.
+	if keepWebhooks {
+		return renameDocumentKey(data, "webhooks", webhooksExtension)
+	}
+
+	return data, nil
+}
+
+// promote30WebhooksExtensionTo31 replaces the `x-webhooks` extension in a 3.0 document with webhooks.
+func promote30WebhooksExtensionTo31(data []byte, report *Report) ([]byte, error) {
+	var root yaml.Node
+
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, &ParseError{Err: err}
+	}
+
+	webhooks := mappingValue(documentRoot(&root), webhooksExtension)
+
+	if webhooks == nil || webhooks.Kind != yaml.MappingNode {
+		return data, nil
+	}
+
+	if mappingValue(documentRoot(&root), "webhooks") != nil {
+		report.Add(Diagnostic{
+			Pointer:  "/" + webhooksExtension,
+			Rule:     "extension-to-webhooks",
+			Severity: SeverityWarning,
+			Message:  "Kept x-webhooks, as the document has webhooks already",
+		})
+
+		return data, nil
+	}
+
+	report.Add(Diagnostic{
+		Pointer:  "/" + webhooksExtension,
+		Rule:     "extension-to-webhooks",
+		Severity: SeverityInfo,
+		Message:  "Moved x-webhooks to webhooks",
+		Before:   mappingKeys(webhooks),
+	})
+
+	return renameDocumentKey(data, webhooksExtension, "webhooks")
+}
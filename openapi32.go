@@ -0,0 +1,705 @@
+package openapispecconverter
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// openAPI32Field is a field only supported in 3.2, which is kept in an extension in earlier versions.
+type openAPI32Field struct {
+	name      string
+	extension string
+	// severity is the severity of moving the field to the extension, as tools will ignore it.
+	severity Severity
+}
+
+// openAPI32RootFields are fields at the top of a 3.2 document.
+var openAPI32RootFields = []openAPI32Field{
+	{name: "$self", extension: "x-self", severity: SeverityWarning},
+}
+
+// openAPI32PathItemFields are fields in 3.2 path items.
+var openAPI32PathItemFields = []openAPI32Field{
+	{name: "query", extension: "x-query", severity: SeverityWarning},
+	{name: "additionalOperations", extension: "x-additionalOperations", severity: SeverityWarning},
+}
+
+// openAPI32TagFields are fields in 3.2 tags for tag hierarchies.
+var openAPI32TagFields = []openAPI32Field{
+	{name: "summary", extension: "x-summary", severity: SeverityInfo},
+	{name: "parent", extension: "x-parent", severity: SeverityInfo},
+	{name: "kind", extension: "x-kind", severity: SeverityInfo},
+}
+
+// openAPI32MediaTypeFields are fields in 3.2 media types for streaming and sequential media types.
+var openAPI32MediaTypeFields = []openAPI32Field{
+	{name: "itemSchema", extension: "x-itemSchema", severity: SeverityWarning},
+	{name: "itemEncoding", extension: "x-itemEncoding", severity: SeverityWarning},
+	{name: "prefixEncoding", extension: "x-prefixEncoding", severity: SeverityWarning},
+}
+
+// openAPI32ComponentsFields are fields in 3.2 components.
+var openAPI32ComponentsFields = []openAPI32Field{
+	{name: "mediaTypes", extension: "x-mediaTypes", severity: SeverityWarning},
+}
+
+const (
+	// querystringExtension holds the `in: querystring` parameters of a path item or operation before 3.2.
+	querystringExtension = "x-querystring"
+	// querystringComponentsExtension holds the `in: querystring` parameters of components before 3.2.
+	querystringComponentsExtension = "x-querystringParameters"
+	// mediaTypeReferencePrefix is the start of references to 3.2 media type components.
+	mediaTypeReferencePrefix = "#/components/mediaTypes/"
+	parameterReferencePrefix = "#/components/parameters/"
+	// querystringReferencePrefix is the start of references to querystring parameters kept in components.
+	querystringReferencePrefix = "#/components/" + querystringComponentsExtension + "/"
+)
+
+// openAPI32ExtensionNames are the extensions holding operations, parameters, and schemas
+// libopenapi doesn't load, which need converting on their own.
+var openAPI32ExtensionNames = []string{
+	"x-query",
+	"x-additionalOperations",
+	"x-itemSchema",
+	"x-mediaTypes",
+	querystringExtension,
+	querystringComponentsExtension,
+}
+
+// pathItemMethods are the fields for operations in every version of path items.
+var pathItemMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// renameMappingKey renames a key in a YAML mapping node, and returns the value for the key.
+//
+// The key is left alone if the new key is set already.
+func renameMappingKey(node *yaml.Node, from string, to string) *yaml.Node {
+	if mappingValue(node, to) != nil {
+		return nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == from {
+			node.Content[i].Value = to
+
+			return node.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+// openAPI32Walker finds every location of fields added in 3.2 in a YAML document.
+//
+// Fields are found under either their 3.2 name or their extension name, so documents can be walked in both directions.
+type openAPI32Walker struct {
+	pathItem  func(node *yaml.Node, pointer string)
+	tag       func(node *yaml.Node, pointer string)
+	mediaType func(node *yaml.Node, pointer string)
+	// parameters is called with each path item or operation after its parameters.
+	parameters func(node *yaml.Node, pointer string)
+}
+
+func (walker *openAPI32Walker) walkMapping(node *yaml.Node, pointer string, callback func(node *yaml.Node, pointer string)) {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		callback(node.Content[i+1], joinPointer(pointer, node.Content[i].Value))
+	}
+}
+
+func (walker *openAPI32Walker) walkContent(node *yaml.Node, pointer string) {
+	walker.walkMapping(mappingValue(node, "content"), joinPointer(pointer, "content"), walker.mediaType)
+}
+
+func (walker *openAPI32Walker) walkHeaders(node *yaml.Node, pointer string) {
+	walker.walkMapping(mappingValue(node, "headers"), joinPointer(pointer, "headers"), walker.walkContent)
+}
+
+func (walker *openAPI32Walker) walkResponse(node *yaml.Node, pointer string) {
+	walker.walkHeaders(node, pointer)
+	walker.walkContent(node, pointer)
+}
+
+func (walker *openAPI32Walker) walkParameters(node *yaml.Node, pointer string) {
+	for _, field := range []string{"parameters", querystringExtension} {
+		if parameters := mappingValue(node, field); parameters != nil && parameters.Kind == yaml.SequenceNode {
+			for i, parameter := range parameters.Content {
+				walker.walkContent(parameter, joinPointer(pointer, field, strconv.Itoa(i)))
+			}
+		}
+	}
+
+	if walker.parameters != nil {
+		walker.parameters(node, pointer)
+	}
+}
+
+func (walker *openAPI32Walker) walkCallbacks(node *yaml.Node, pointer string) {
+	walker.walkMapping(mappingValue(node, "callbacks"), joinPointer(pointer, "callbacks"), func(callback *yaml.Node, pointer string) {
+		walker.walkMapping(callback, pointer, walker.walkPathItem)
+	})
+}
+
+func (walker *openAPI32Walker) walkOperation(node *yaml.Node, pointer string) {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return
+	}
+
+	walker.walkParameters(node, pointer)
+
+	if requestBody := mappingValue(node, "requestBody"); requestBody != nil {
+		walker.walkContent(requestBody, joinPointer(pointer, "requestBody"))
+	}
+
+	walker.walkMapping(mappingValue(node, "responses"), joinPointer(pointer, "responses"), walker.walkResponse)
+	walker.walkCallbacks(node, pointer)
+}
+
+func (walker *openAPI32Walker) walkPathItem(node *yaml.Node, pointer string) {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return
+	}
+
+	walker.walkParameters(node, pointer)
+
+	for _, method := range pathItemMethods {
+		walker.walkOperation(mappingValue(node, method), joinPointer(pointer, method))
+	}
+
+	for _, field := range []string{"query", "x-query"} {
+		walker.walkOperation(mappingValue(node, field), joinPointer(pointer, field))
+	}
+
+	for _, field := range []string{"additionalOperations", "x-additionalOperations"} {
+		walker.walkMapping(mappingValue(node, field), joinPointer(pointer, field), walker.walkOperation)
+	}
+
+	// Update the path item last, so pointers to operations in it are not changed before they are used.
+	walker.pathItem(node, pointer)
+}
+
+func (walker *openAPI32Walker) walkDocument(root *yaml.Node) {
+	walker.walkMapping(mappingValue(root, "paths"), "/paths", walker.walkPathItem)
+	walker.walkMapping(mappingValue(root, "webhooks"), "/webhooks", walker.walkPathItem)
+
+	if tags := mappingValue(root, "tags"); tags != nil && tags.Kind == yaml.SequenceNode {
+		for i, tag := range tags.Content {
+			walker.tag(tag, joinPointer("/tags", strconv.Itoa(i)))
+		}
+	}
+
+	components := mappingValue(root, "components")
+
+	walker.walkMapping(mappingValue(components, "pathItems"), "/components/pathItems", walker.walkPathItem)
+	walker.walkMapping(mappingValue(components, "callbacks"), "/components/callbacks", func(callback *yaml.Node, pointer string) {
+		walker.walkMapping(callback, pointer, walker.walkPathItem)
+	})
+	walker.walkMapping(mappingValue(components, "parameters"), "/components/parameters", walker.walkContent)
+	walker.walkMapping(mappingValue(components, "requestBodies"), "/components/requestBodies", walker.walkContent)
+	walker.walkMapping(mappingValue(components, "responses"), "/components/responses", walker.walkResponse)
+	walker.walkMapping(mappingValue(components, "headers"), "/components/headers", walker.walkContent)
+
+	for _, field := range []string{"mediaTypes", "x-mediaTypes"} {
+		walker.walkMapping(mappingValue(components, field), joinPointer("/components", field), walker.mediaType)
+	}
+}
+
+// moveOpenAPI32Fields moves 3.2 fields in a node to extensions, or extensions to 3.2 fields.
+func moveOpenAPI32Fields(node *yaml.Node, pointer string, fields []openAPI32Field, toExtensions bool, report *Report) {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return
+	}
+
+	for _, field := range fields {
+		if toExtensions {
+			if value := renameMappingKey(node, field.name, field.extension); value != nil {
+				report.Add(Diagnostic{
+					Pointer:  joinPointer(pointer, field.name),
+					Rule:     "field-to-extension",
+					Severity: field.severity,
+					Message:  "Moved " + field.name + " to " + field.extension + ", as it is not supported before 3.2",
+					Before:   nodeValue(value),
+				})
+			}
+		} else if value := renameMappingKey(node, field.extension, field.name); value != nil {
+			report.Add(Diagnostic{
+				Pointer:  joinPointer(pointer, field.extension),
+				Rule:     "extension-to-field",
+				Severity: SeverityInfo,
+				Message:  "Moved " + field.extension + " to " + field.name,
+				Before:   nodeValue(value),
+			})
+		}
+	}
+}
+
+// isQuerystringParameter returns true for `in: querystring` parameters, or references to them in components.
+func isQuerystringParameter(root *yaml.Node, parameter *yaml.Node) bool {
+	if ref := mappingValue(parameter, "$ref"); ref != nil {
+		if name, ok := strings.CutPrefix(ref.Value, parameterReferencePrefix); ok {
+			components := mappingValue(root, "components")
+			parameter = mappingValue(mappingValue(components, "parameters"), name)
+
+			if parameter == nil {
+				parameter = mappingValue(mappingValue(components, querystringComponentsExtension), name)
+			}
+		}
+	}
+
+	in := mappingValue(parameter, "in")
+
+	return in != nil && in.Value == "querystring"
+}
+
+// replaceParameterReference replaces the start of a reference to a parameter component.
+func replaceParameterReference(parameter *yaml.Node, oldPrefix string, newPrefix string) {
+	if ref := mappingValue(parameter, "$ref"); ref != nil {
+		if name, ok := strings.CutPrefix(ref.Value, oldPrefix); ok {
+			ref.Value = newPrefix + name
+		}
+	}
+}
+
+// moveQuerystringParameters moves `in: querystring` parameters of a path item or operation to an extension,
+// or from the extension back to the parameters.
+func moveQuerystringParameters(root *yaml.Node, node *yaml.Node, pointer string, toExtensions bool, report *Report) {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return
+	}
+
+	if !toExtensions {
+		if moved := removeMappingKey(node, querystringExtension); moved != nil && moved.Kind == yaml.SequenceNode {
+			parameters := mappingValue(node, "parameters")
+
+			if parameters == nil || parameters.Kind != yaml.SequenceNode {
+				parameters = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+				setMappingValue(node, "parameters", parameters)
+			}
+
+			for _, parameter := range moved.Content {
+				replaceParameterReference(parameter, querystringReferencePrefix, parameterReferencePrefix)
+			}
+
+			parameters.Content = append(parameters.Content, moved.Content...)
+
+			report.Add(Diagnostic{
+				Pointer:  joinPointer(pointer, querystringExtension),
+				Rule:     "extension-to-field",
+				Severity: SeverityInfo,
+				Message:  "Moved " + querystringExtension + " to parameters",
+				Before:   nodeValue(moved),
+			})
+		}
+
+		return
+	}
+
+	parameters := mappingValue(node, "parameters")
+
+	if parameters == nil || parameters.Kind != yaml.SequenceNode || mappingValue(node, querystringExtension) != nil {
+		return
+	}
+
+	moved := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	kept := parameters.Content[:0:0]
+
+	for i, parameter := range parameters.Content {
+		if !isQuerystringParameter(root, parameter) {
+			kept = append(kept, parameter)
+
+			continue
+		}
+
+		before := nodeValue(parameter)
+		replaceParameterReference(parameter, parameterReferencePrefix, querystringReferencePrefix)
+		moved.Content = append(moved.Content, parameter)
+
+		report.Add(Diagnostic{
+			Pointer:  joinPointer(pointer, "parameters", strconv.Itoa(i)),
+			Rule:     "field-to-extension",
+			Severity: SeverityWarning,
+			Message:  "Moved the querystring parameter to " + querystringExtension + ", as it is not supported before 3.2",
+			Before:   before,
+		})
+	}
+
+	if len(moved.Content) == 0 {
+		return
+	}
+
+	if len(kept) == 0 {
+		removeMappingKey(node, "parameters")
+	} else {
+		parameters.Content = kept
+	}
+
+	setMappingValue(node, querystringExtension, moved)
+}
+
+// moveQuerystringComponents moves `in: querystring` parameters of components to an extension,
+// or from the extension back to the parameters of components.
+func moveQuerystringComponents(components *yaml.Node, toExtensions bool, report *Report) {
+	if components == nil || components.Kind != yaml.MappingNode {
+		return
+	}
+
+	from, to := "parameters", querystringComponentsExtension
+
+	if !toExtensions {
+		from, to = to, from
+	}
+
+	parameters := mappingValue(components, from)
+
+	if parameters == nil || parameters.Kind != yaml.MappingNode {
+		return
+	}
+
+	moved := mappingValue(components, to)
+
+	for i := 0; i+1 < len(parameters.Content); {
+		name, parameter := parameters.Content[i].Value, parameters.Content[i+1]
+		in := mappingValue(parameter, "in")
+
+		if toExtensions && (in == nil || in.Value != "querystring") {
+			i += 2
+
+			continue
+		}
+
+		if moved == nil {
+			moved = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			setMappingValue(components, to, moved)
+		}
+
+		setMappingValue(moved, name, parameter)
+		parameters.Content = append(parameters.Content[:i], parameters.Content[i+2:]...)
+
+		if toExtensions {
+			report.Add(Diagnostic{
+				Pointer:  joinPointer("/components/parameters", name),
+				Rule:     "field-to-extension",
+				Severity: SeverityWarning,
+				Message:  "Moved the querystring parameter to " + querystringComponentsExtension + ", as it is not supported before 3.2",
+				Before:   nodeValue(parameter),
+			})
+		} else {
+			report.Add(Diagnostic{
+				Pointer:  joinPointer("/components", querystringComponentsExtension, name),
+				Rule:     "extension-to-field",
+				Severity: SeverityInfo,
+				Message:  "Moved " + querystringComponentsExtension + " to parameters",
+				Before:   nodeValue(parameter),
+			})
+		}
+	}
+
+	if len(parameters.Content) == 0 {
+		removeMappingKey(components, from)
+	}
+}
+
+// inlineMediaTypeReference replaces a reference to a 3.2 media type component with a copy of the media type,
+// as there are no media type components before 3.2.
+func inlineMediaTypeReference(root *yaml.Node, node *yaml.Node, pointer string, report *Report) {
+	ref := mappingValue(node, "$ref")
+
+	if ref == nil {
+		return
+	}
+
+	name, ok := strings.CutPrefix(ref.Value, mediaTypeReferencePrefix)
+
+	if !ok {
+		return
+	}
+
+	components := mappingValue(root, "components")
+	mediaType := mappingValue(mappingValue(components, "mediaTypes"), name)
+
+	if mediaType == nil {
+		mediaType = mappingValue(mappingValue(components, "x-mediaTypes"), name)
+	}
+
+	if mediaType == nil {
+		return
+	}
+
+	*node = *copyNode(mediaType)
+
+	report.Add(Diagnostic{
+		Pointer:  pointer,
+		Rule:     "media-type-reference-inlined",
+		Severity: SeverityInfo,
+		Message:  "Replaced the reference to a media type component with the media type, as media type components are not supported before 3.2",
+		Before:   map[string]any{"$ref": ref.Value},
+	})
+}
+
+// convertOpenAPI32Fields sets the version of a document, and moves every 3.2 field to or from extensions.
+func convertOpenAPI32Fields(data []byte, version SpecVersion, report *Report) ([]byte, error) {
+	toExtensions := version != OpenAPI32
+
+	return patchDocument(data, []string{""}, func(root *yaml.Node, pointer string) {
+		if root.Kind != yaml.MappingNode {
+			return
+		}
+
+		walker := openAPI32Walker{
+			pathItem: func(node *yaml.Node, pointer string) {
+				moveOpenAPI32Fields(node, pointer, openAPI32PathItemFields, toExtensions, report)
+			},
+			tag: func(node *yaml.Node, pointer string) {
+				moveOpenAPI32Fields(node, pointer, openAPI32TagFields, toExtensions, report)
+			},
+			mediaType: func(node *yaml.Node, pointer string) {
+				if toExtensions && !strings.HasPrefix(pointer, "/components/mediaTypes/") {
+					inlineMediaTypeReference(root, node, pointer, report)
+				}
+
+				moveOpenAPI32Fields(node, pointer, openAPI32MediaTypeFields, toExtensions, report)
+			},
+			parameters: func(node *yaml.Node, pointer string) {
+				moveQuerystringParameters(root, node, pointer, toExtensions, report)
+			},
+		}
+
+		walker.walkDocument(root)
+		moveOpenAPI32Fields(root, "", openAPI32RootFields, toExtensions, report)
+		moveQuerystringComponents(mappingValue(root, "components"), toExtensions, report)
+		moveOpenAPI32Fields(mappingValue(root, "components"), "/components", openAPI32ComponentsFields, toExtensions, report)
+
+		setMappingValue(root, "openapi", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: version.versionName()})
+	})
+}
+
+// convertOpenAPI31To32 converts a 3.1 document to 3.2.
+//
+// 3.2 only adds to 3.1, so we only need to set the version, and move fields
+// kept in extensions when converting 3.2 to 3.1 back again.
+func convertOpenAPI31To32(data []byte, report *Report) ([]byte, error) {
+	return convertOpenAPI32Fields(data, OpenAPI32, report)
+}
+
+// convertOpenAPI32To31 converts a 3.2 document to 3.1.
+//
+// libopenapi doesn't load fields added in 3.2, so we move them to extensions
+// in the YAML document instead of the model, so they are kept.
+func convertOpenAPI32To31(data []byte, report *Report) ([]byte, error) {
+	return convertOpenAPI32Fields(data, OpenAPI31, report)
+}
+
+// openAPI32ExtensionContent is an operation, parameter, or schema kept in an extension before 3.2.
+type openAPI32ExtensionContent struct {
+	pointer string
+	// component is the components field for parameters and schemas, and empty for operations.
+	component string
+}
+
+// findOpenAPI32ExtensionContent finds every operation, parameter, and schema kept in an extension,
+// with content inside other content found first.
+func findOpenAPI32ExtensionContent(root *yaml.Node) []openAPI32ExtensionContent {
+	var result []openAPI32ExtensionContent
+	var walker openAPI32Walker
+
+	addParameters := func(node *yaml.Node, pointer string) {
+		if parameters := mappingValue(node, querystringExtension); parameters != nil && parameters.Kind == yaml.SequenceNode {
+			for i, parameter := range parameters.Content {
+				// References are converted with the components they point to.
+				if mappingValue(parameter, "$ref") != nil {
+					continue
+				}
+
+				result = append(result, openAPI32ExtensionContent{
+					pointer:   joinPointer(pointer, querystringExtension, strconv.Itoa(i)),
+					component: "parameters",
+				})
+			}
+		}
+	}
+
+	walker = openAPI32Walker{
+		pathItem: func(node *yaml.Node, pointer string) {
+			if mappingValue(node, "x-query") != nil {
+				result = append(result, openAPI32ExtensionContent{pointer: joinPointer(pointer, "x-query")})
+			}
+
+			walker.walkMapping(
+				mappingValue(node, "x-additionalOperations"),
+				joinPointer(pointer, "x-additionalOperations"),
+				func(operation *yaml.Node, pointer string) {
+					result = append(result, openAPI32ExtensionContent{pointer: pointer})
+				},
+			)
+		},
+		tag: func(node *yaml.Node, pointer string) {},
+		mediaType: func(node *yaml.Node, pointer string) {
+			if mappingValue(node, "x-itemSchema") != nil {
+				result = append(result, openAPI32ExtensionContent{
+					pointer:   joinPointer(pointer, "x-itemSchema"),
+					component: "schemas",
+				})
+			}
+
+			if strings.HasPrefix(pointer, "/components/x-mediaTypes/") && mappingValue(node, "schema") != nil {
+				result = append(result, openAPI32ExtensionContent{pointer: joinPointer(pointer, "schema"), component: "schemas"})
+			}
+		},
+		parameters: addParameters,
+	}
+
+	walker.walkDocument(root)
+
+	components := mappingValue(root, "components")
+
+	walker.walkMapping(
+		mappingValue(components, querystringComponentsExtension),
+		joinPointer("/components", querystringComponentsExtension),
+		func(parameter *yaml.Node, pointer string) {
+			result = append(result, openAPI32ExtensionContent{pointer: pointer, component: "parameters"})
+		},
+	)
+
+	return result
+}
+
+// convertOpenAPI32ExtensionNode converts an operation, parameter, or schema kept in an extension
+// by converting a document with only the content and the components it can reference.
+//
+// Diagnostics for the content are added to the report with pointers to the extension.
+func convertOpenAPI32ExtensionNode(
+	root *yaml.Node,
+	node *yaml.Node,
+	content openAPI32ExtensionContent,
+	convert func(data []byte, report *Report) ([]byte, error),
+	report *Report,
+) (*yaml.Node, error) {
+	components := copyNode(mappingValue(root, "components"))
+
+	if components == nil {
+		components = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	}
+
+	document := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	setMappingValue(document, "openapi", mappingValue(root, "openapi"))
+	setMappingValue(document, "info", &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{
+		scalarNode("!!str", "title"), scalarNode("!!str", ""),
+		scalarNode("!!str", "version"), scalarNode("!!str", ""),
+	}})
+
+	paths := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	contentPointer := "/paths/~1/get"
+
+	if len(content.component) > 0 {
+		componentMap := mappingValue(components, content.component)
+
+		if componentMap == nil {
+			componentMap = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			setMappingValue(components, content.component, componentMap)
+		}
+
+		name := "Extension"
+
+		for mappingValue(componentMap, name) != nil {
+			name += "_"
+		}
+
+		setMappingValue(componentMap, name, node)
+		contentPointer = joinPointer("/components", content.component, name)
+	} else {
+		setMappingValue(paths, "/", &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{
+			scalarNode("!!str", "get"), node,
+		}})
+	}
+
+	setMappingValue(document, "paths", paths)
+	setMappingValue(document, "components", components)
+
+	data, err := yaml.Marshal(document)
+
+	if err != nil {
+		return nil, fmt.Errorf("Error rendering %s: %w", content.pointer, err)
+	}
+
+	contentReport := &Report{}
+
+	if data, err = convert(data, contentReport); err != nil {
+		return nil, fmt.Errorf("Error converting %s: %w", content.pointer, err)
+	}
+
+	var converted yaml.Node
+
+	if err := yaml.Unmarshal(data, &converted); err != nil {
+		return nil, fmt.Errorf("Error loading converted %s: %w", content.pointer, err)
+	}
+
+	for _, diagnostic := range contentReport.Diagnostics {
+		if rest, ok := strings.CutPrefix(diagnostic.Pointer, contentPointer); ok && (rest == "" || rest[0] == '/') {
+			diagnostic.Pointer = content.pointer + rest
+			report.Add(diagnostic)
+		}
+	}
+
+	return findPointer(&converted, contentPointer)
+}
+
+// convertOpenAPI32ExtensionContent converts operations, parameters, and schemas kept in extensions before 3.2,
+// which libopenapi doesn't load, so they are converted along with the rest of the document.
+func convertOpenAPI32ExtensionContent(
+	data []byte,
+	convert func(data []byte, report *Report) ([]byte, error),
+	report *Report,
+) ([]byte, error) {
+	found := false
+
+	for _, name := range openAPI32ExtensionNames {
+		found = found || bytes.Contains(data, []byte(name))
+	}
+
+	if !found {
+		return data, nil
+	}
+
+	var root yaml.Node
+
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, &ParseError{Err: err}
+	}
+
+	contents := findOpenAPI32ExtensionContent(documentRoot(&root))
+	pointers := make([]string, len(contents))
+
+	for i, content := range contents {
+		pointers[i] = content.pointer
+	}
+
+	var err error
+	i := 0
+
+	data, patchErr := patchDocument(data, pointers, func(node *yaml.Node, pointer string) {
+		content := contents[i]
+		i++
+
+		if err != nil {
+			return
+		}
+
+		var converted *yaml.Node
+
+		if converted, err = convertOpenAPI32ExtensionNode(documentRoot(&root), node, content, convert, report); err == nil {
+			*node = *converted
+		}
+	})
+
+	if patchErr != nil {
+		return nil, patchErr
+	}
+
+	return data, err
+}
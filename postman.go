@@ -0,0 +1,491 @@
+package openapispecconverter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pb33f/libopenapi"
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/libopenapi/orderedmap"
+)
+
+// postmanSchema is the URL of the schema for Postman Collection v2.1 documents.
+const postmanSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// postmanBaseURLVariable is the collection variable for the URL of the first server.
+const postmanBaseURLVariable = "baseUrl"
+
+// Types for Postman Collection v2.1 documents, with the fields we set.
+type (
+	postmanCollection struct {
+		Info     postmanInfo       `json:"info"`
+		Item     []*postmanItem    `json:"item"`
+		Variable []postmanVariable `json:"variable,omitempty"`
+	}
+	postmanInfo struct {
+		Name        string `json:"name"`
+		Description string `json:"description,omitempty"`
+		Schema      string `json:"schema"`
+	}
+	postmanVariable struct {
+		Key         string `json:"key"`
+		Value       string `json:"value"`
+		Description string `json:"description,omitempty"`
+		Type        string `json:"type"`
+	}
+	// postmanItem is a folder when Item is set, and a request otherwise.
+	postmanItem struct {
+		Name        string            `json:"name"`
+		Description string            `json:"description,omitempty"`
+		Item        []*postmanItem    `json:"item,omitempty"`
+		Request     *postmanRequest   `json:"request,omitempty"`
+		Response    []postmanResponse `json:"response,omitempty"`
+	}
+	postmanRequest struct {
+		Method      string            `json:"method"`
+		Header      []postmanKeyValue `json:"header"`
+		Body        *postmanBody      `json:"body,omitempty"`
+		URL         postmanURL        `json:"url"`
+		Description string            `json:"description,omitempty"`
+	}
+	postmanKeyValue struct {
+		Key         string `json:"key"`
+		Value       string `json:"value"`
+		Description string `json:"description,omitempty"`
+		Type        string `json:"type,omitempty"`
+		Disabled    bool   `json:"disabled,omitempty"`
+	}
+	postmanURL struct {
+		Raw      string            `json:"raw"`
+		Host     []string          `json:"host"`
+		Path     []string          `json:"path"`
+		Query    []postmanKeyValue `json:"query,omitempty"`
+		Variable []postmanKeyValue `json:"variable,omitempty"`
+	}
+	postmanBody struct {
+		Mode       string             `json:"mode"`
+		Raw        string             `json:"raw,omitempty"`
+		URLEncoded []postmanKeyValue  `json:"urlencoded,omitempty"`
+		FormData   []postmanKeyValue  `json:"formdata,omitempty"`
+		Options    *postmanBodyOption `json:"options,omitempty"`
+	}
+	postmanBodyOption struct {
+		Raw struct {
+			Language string `json:"language"`
+		} `json:"raw"`
+	}
+	postmanResponse struct {
+		Name            string            `json:"name"`
+		OriginalRequest *postmanRequest   `json:"originalRequest,omitempty"`
+		Status          string            `json:"status,omitempty"`
+		Code            int               `json:"code,omitempty"`
+		Header          []postmanKeyValue `json:"header"`
+		Body            string            `json:"body"`
+		PreviewLanguage string            `json:"_postman_previewlanguage,omitempty"`
+	}
+)
+
+// parameterExample returns an example value for a parameter as a string.
+func parameterExample(parameter *v3.Parameter) string {
+	var value any
+
+	switch {
+	case parameter.Example != nil:
+		value = nodeValue(parameter.Example)
+	case parameter.Examples != nil && parameter.Examples.Len() > 0:
+		if example := parameter.Examples.Oldest().Value; example != nil && example.Value != nil {
+			value = nodeValue(example.Value)
+		}
+	default:
+		value = exampleValue(exampleGenerator{request: true}.schema(parameter.Schema))
+	}
+
+	return postmanValue(value)
+}
+
+// postmanValue formats a value as a string for a parameter, header, or form field.
+func postmanValue(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case []any:
+		values := make([]string, 0, len(value))
+
+		for _, item := range value {
+			values = append(values, postmanValue(item))
+		}
+
+		return strings.Join(values, ",")
+	case map[string]any:
+		data, _ := json.Marshal(value)
+
+		return string(data)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// isJSONMediaType returns true for JSON media types, such as application/json or application/problem+json.
+func isJSONMediaType(mediaType string) bool {
+	mediaType, _, _ = strings.Cut(mediaType, ";")
+
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// postmanBodyLanguage returns the language Postman highlights a body of a media type as.
+func postmanBodyLanguage(mediaType string) string {
+	switch {
+	case isJSONMediaType(mediaType):
+		return "json"
+	case strings.Contains(mediaType, "xml"):
+		return "xml"
+	case strings.HasPrefix(mediaType, "text/html"):
+		return "html"
+	default:
+		return "text"
+	}
+}
+
+// rawBody formats an example value as the raw body of a request or response.
+func rawBody(value any, mediaType string) string {
+	if text, ok := value.(string); ok && !isJSONMediaType(mediaType) {
+		return text
+	}
+
+	data, err := json.MarshalIndent(value, "", "  ")
+
+	if err != nil {
+		return ""
+	}
+
+	return string(data)
+}
+
+// postmanRequestBody creates the body of a request from the first media type of a request body.
+func postmanRequestBody(requestBody *v3.RequestBody) (string, *postmanBody) {
+	if requestBody == nil || requestBody.Content == nil || requestBody.Content.Len() == 0 {
+		return "", nil
+	}
+
+	pair := requestBody.Content.Oldest()
+	contentType, mediaType := pair.Key, pair.Value
+
+	switch {
+	case strings.HasPrefix(contentType, "application/x-www-form-urlencoded"),
+		strings.HasPrefix(contentType, "multipart/form-data"):
+		body := &postmanBody{Mode: "urlencoded"}
+		example, _ := exampleValue(exampleGenerator{request: true}.mediaType(mediaType)).(map[string]any)
+		var properties *orderedmap.Map[string, *base.SchemaProxy]
+
+		if mediaType.Schema != nil && mediaType.Schema.Schema() != nil {
+			properties = mediaType.Schema.Schema().Properties
+		}
+
+		for name, property := range properties.FromOldest() {
+			field := postmanKeyValue{Key: name, Value: postmanValue(example[name]), Type: "text"}
+
+			if schema := property.Schema(); schema != nil {
+				field.Description = schema.Description
+
+				// Files are strings with a binary format in 3.0, and a contentMediaType in 3.1.
+				if schema.Format == "binary" || (schema.GoLow() != nil && len(schema.GoLow().ContentMediaType.Value) > 0) {
+					field.Type = "file"
+					field.Value = ""
+				}
+			}
+
+			if strings.HasPrefix(contentType, "multipart/form-data") {
+				body.Mode = "formdata"
+				body.FormData = append(body.FormData, field)
+			} else {
+				field.Type = ""
+				body.URLEncoded = append(body.URLEncoded, field)
+			}
+		}
+
+		return contentType, body
+	default:
+		body := &postmanBody{
+			Mode:    "raw",
+			Raw:     rawBody(exampleValue(exampleGenerator{request: true}.mediaType(mediaType)), contentType),
+			Options: &postmanBodyOption{},
+		}
+		body.Options.Raw.Language = postmanBodyLanguage(contentType)
+
+		return contentType, body
+	}
+}
+
+// postmanResponses creates an example response for every response of an operation.
+func postmanResponses(responses *v3.Responses, request *postmanRequest) []postmanResponse {
+	if responses == nil {
+		return nil
+	}
+
+	var result []postmanResponse
+	codes := responses.Codes
+
+	if responses.Default != nil {
+		codes = orderedmap.New[string, *v3.Response]()
+
+		for code, response := range responses.Codes.FromOldest() {
+			codes.Set(code, response)
+		}
+
+		codes.Set("default", responses.Default)
+	}
+
+	for code, response := range codes.FromOldest() {
+		if response == nil {
+			continue
+		}
+
+		// Ranges such as 2XX use the first code in the range, and default responses have no code.
+		statusCode, _ := strconv.Atoi(strings.ReplaceAll(strings.ToUpper(code), "X", "0"))
+		name := response.Description
+
+		if len(name) == 0 {
+			name = code
+		}
+
+		postmanResponse := postmanResponse{
+			Name:            name,
+			OriginalRequest: request,
+			Status:          http.StatusText(statusCode),
+			Code:            statusCode,
+			Header:          []postmanKeyValue{},
+		}
+
+		if response.Content != nil && response.Content.Len() > 0 {
+			pair := response.Content.Oldest()
+			postmanResponse.Header = append(postmanResponse.Header, postmanKeyValue{Key: "Content-Type", Value: pair.Key})
+			postmanResponse.Body = rawBody(exampleValue(exampleGenerator{}.mediaType(pair.Value)), pair.Key)
+			postmanResponse.PreviewLanguage = postmanBodyLanguage(pair.Key)
+		}
+
+		result = append(result, postmanResponse)
+	}
+
+	return result
+}
+
+// serverVariablePattern matches variables in server URLs and paths.
+var serverVariablePattern = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// postmanVariables returns collection variables for the first server, and its server variables.
+func postmanVariables(servers []*v3.Server) []postmanVariable {
+	baseURL := ""
+	var variables []postmanVariable
+
+	if len(servers) > 0 {
+		server := servers[0]
+		baseURL = strings.TrimSuffix(serverVariablePattern.ReplaceAllString(server.URL, "{{$1}}"), "/")
+
+		if server.Variables != nil {
+			for name, variable := range server.Variables.FromOldest() {
+				description := variable.Description
+
+				if len(variable.Enum) > 0 {
+					description = strings.TrimSpace(description + " (one of: " + strings.Join(variable.Enum, ", ") + ")")
+				}
+
+				variables = append(variables, postmanVariable{
+					Key:         name,
+					Value:       variable.Default,
+					Description: description,
+					Type:        "string",
+				})
+			}
+		}
+	}
+
+	return append(
+		[]postmanVariable{{Key: postmanBaseURLVariable, Value: baseURL, Type: "string"}},
+		variables...,
+	)
+}
+
+// postmanOperationRequest creates a Postman request for an operation.
+func postmanOperationRequest(path string, method string, pathItem *v3.PathItem, operation *v3.Operation) *postmanRequest {
+	request := &postmanRequest{
+		Method:      strings.ToUpper(method),
+		Header:      []postmanKeyValue{},
+		Description: operation.Description,
+		URL: postmanURL{
+			Host: []string{"{{" + postmanBaseURLVariable + "}}"},
+			Path: []string{},
+		},
+	}
+
+	// Operation parameters override path item parameters with the same name and location.
+	var parameters []*v3.Parameter
+
+	for _, parameter := range append(pathItem.Parameters, operation.Parameters...) {
+		if parameter == nil {
+			continue
+		}
+
+		parameters = slices.DeleteFunc(parameters, func(other *v3.Parameter) bool {
+			return other.Name == parameter.Name && other.In == parameter.In
+		})
+		parameters = append(parameters, parameter)
+	}
+
+	var cookies []string
+
+	for _, parameter := range parameters {
+		value := postmanKeyValue{
+			Key:         parameter.Name,
+			Value:       parameterExample(parameter),
+			Description: parameter.Description,
+		}
+
+		switch parameter.In {
+		case "path":
+			request.URL.Variable = append(request.URL.Variable, value)
+		case "query":
+			value.Disabled = parameter.Required == nil || !*parameter.Required
+			request.URL.Query = append(request.URL.Query, value)
+		case "header":
+			request.Header = append(request.Header, value)
+		case "cookie":
+			cookies = append(cookies, parameter.Name+"="+value.Value)
+		}
+	}
+
+	if len(cookies) > 0 {
+		request.Header = append(request.Header, postmanKeyValue{Key: "Cookie", Value: strings.Join(cookies, "; ")})
+	}
+
+	contentType, body := postmanRequestBody(operation.RequestBody)
+
+	if body != nil {
+		request.Body = body
+
+		// Postman sets the Content-Type header for form data itself, including the multipart boundary.
+		if body.Mode == "raw" {
+			request.Header = append(request.Header, postmanKeyValue{Key: "Content-Type", Value: contentType})
+		}
+	}
+
+	for _, segment := range strings.Split(strings.Trim(path, "/"), "/") {
+		if len(segment) > 0 {
+			request.URL.Path = append(request.URL.Path, serverVariablePattern.ReplaceAllString(segment, ":$1"))
+		}
+	}
+
+	request.URL.Raw = "{{" + postmanBaseURLVariable + "}}/" + strings.Join(request.URL.Path, "/")
+	var query []string
+
+	for _, parameter := range request.URL.Query {
+		if !parameter.Disabled {
+			query = append(query, parameter.Key+"="+parameter.Value)
+		}
+	}
+
+	if len(query) > 0 {
+		request.URL.Raw += "?" + strings.Join(query, "&")
+	}
+
+	return request
+}
+
+// convertOpenAPIToPostman converts a 3.1 document to a Postman Collection v2.1.
+//
+// Operations are put in a folder for their first tag, and operations with no tags
+// are put at the top of the collection.
+func convertOpenAPIToPostman(data []byte, report *Report) ([]byte, error) {
+	doc, err := libopenapi.NewDocument(data)
+
+	if err != nil {
+		return nil, fmt.Errorf("Error loading document: %w", err)
+	}
+
+	model, errs := doc.BuildV3Model()
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("Errors loading document: %w", errors.Join(errs...))
+	}
+
+	collection := postmanCollection{
+		Info:     postmanInfo{Schema: postmanSchema},
+		Item:     []*postmanItem{},
+		Variable: postmanVariables(model.Model.Servers),
+	}
+
+	if info := model.Model.Info; info != nil {
+		collection.Info.Name = info.Title
+		collection.Info.Description = info.Description
+	}
+
+	folders := map[string]*postmanItem{}
+
+	for _, tag := range model.Model.Tags {
+		folders[tag.Name] = &postmanItem{Name: tag.Name, Description: tag.Description, Item: []*postmanItem{}}
+		collection.Item = append(collection.Item, folders[tag.Name])
+	}
+
+	if model.Model.Paths != nil {
+		for path, pathItem := range model.Model.Paths.PathItems.FromOldest() {
+			for method, operation := range pathItem.GetOperations().FromOldest() {
+				request := postmanOperationRequest(path, method, pathItem, operation)
+				name := operation.Summary
+
+				if len(name) == 0 {
+					name = operation.OperationId
+				}
+
+				if len(name) == 0 {
+					name = strings.ToUpper(method) + " " + path
+				}
+
+				item := &postmanItem{
+					Name:     name,
+					Request:  request,
+					Response: postmanResponses(operation.Responses, request),
+				}
+
+				if len(operation.Tags) == 0 {
+					collection.Item = append(collection.Item, item)
+
+					continue
+				}
+
+				folder, ok := folders[operation.Tags[0]]
+
+				if !ok {
+					folder = &postmanItem{Name: operation.Tags[0], Item: []*postmanItem{}}
+					folders[operation.Tags[0]] = folder
+					collection.Item = append(collection.Item, folder)
+				}
+
+				folder.Item = append(folder.Item, item)
+			}
+		}
+	}
+
+	// Folders for tags with no operations are left out, as Postman shows them as empty folders.
+	collection.Item = slices.DeleteFunc(collection.Item, func(item *postmanItem) bool {
+		return item.Request == nil && len(item.Item) == 0
+	})
+
+	if model.Model.Webhooks != nil && model.Model.Webhooks.Len() > 0 {
+		report.Add(Diagnostic{
+			Pointer:  "/webhooks",
+			Rule:     "webhooks-removed",
+			Severity: SeverityWarning,
+			Message:  "Removed webhooks, as Postman collections only contain requests sent to the API",
+		})
+	}
+
+	return json.MarshalIndent(collection, "", "  ")
+}
@@ -0,0 +1,142 @@
+package openapispecconverter
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pb33f/libopenapi"
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// wrapReferenceInAllOf replaces `{$ref: X, ...}` with `{allOf: [{$ref: X}], ...}`.
+func wrapReferenceInAllOf(node *yaml.Node) {
+	var refContent []*yaml.Node
+	var siblings []*yaml.Node
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == "$ref" {
+			refContent = []*yaml.Node{node.Content[i], node.Content[i+1]}
+		} else {
+			siblings = append(siblings, node.Content[i], node.Content[i+1])
+		}
+	}
+
+	reference := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: refContent}
+
+	// An existing allOf sibling is applied along with the `$ref` already.
+	if allOf := mappingValue(node, "allOf"); allOf != nil && allOf.Kind == yaml.SequenceNode {
+		allOf.Content = append([]*yaml.Node{reference}, allOf.Content...)
+		node.Content = siblings
+
+		return
+	}
+
+	node.Content = append(
+		[]*yaml.Node{
+			{Kind: yaml.ScalarNode, Tag: "!!str", Value: "allOf"},
+			{Kind: yaml.SequenceNode, Tag: "!!seq", Content: []*yaml.Node{reference}},
+		},
+		siblings...,
+	)
+}
+
+// collapseAllOfReference replaces `{allOf: [{$ref: X}], ...}` with `{$ref: X, ...}`.
+func collapseAllOfReference(node *yaml.Node) {
+	allOf := mappingValue(node, "allOf")
+
+	if allOf == nil || allOf.Kind != yaml.SequenceNode || len(allOf.Content) != 1 {
+		return
+	}
+
+	ref := mappingValue(allOf.Content[0], "$ref")
+
+	if ref == nil {
+		return
+	}
+
+	content := []*yaml.Node{{Kind: yaml.ScalarNode, Tag: "!!str", Value: "$ref"}, ref}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value != "allOf" {
+			content = append(content, node.Content[i], node.Content[i+1])
+		}
+	}
+
+	node.Content = content
+}
+
+// wrap31ReferenceSiblingsIn30AllOf wraps every schema `$ref` with sibling keywords in `allOf`.
+//
+// 3.0 ignores keywords next to a `$ref`, but not keywords next to `allOf`.
+// This is done before loading the document to convert it, so the keywords
+// are converted to 3.0 along with every other schema.
+func wrap31ReferenceSiblingsIn30AllOf(data []byte, report *Report) ([]byte, error) {
+	doc, err := libopenapi.NewDocument(data)
+
+	if err != nil {
+		return nil, fmt.Errorf("Error loading document: %w", err)
+	}
+
+	model, errs := doc.BuildV3Model()
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("Errors loading document: %w", errors.Join(errs...))
+	}
+
+	var pointers []string
+
+	updateAllSchemaReferences(model, func(schemaProxy *base.SchemaProxy, pointer string) {
+		// A reference without siblings is a mapping with only `$ref` in it.
+		if node := schemaProxy.GetReferenceNode(); node != nil && len(node.Content) > 2 {
+			pointers = append(pointers, pointer)
+		}
+	})
+
+	return patchDocument(data, pointers, func(node *yaml.Node, pointer string) {
+		before := nodeValue(node)
+		wrapReferenceInAllOf(node)
+
+		report.Add(Diagnostic{
+			Pointer:  pointer,
+			Rule:     "ref-siblings-to-all-of",
+			Severity: SeverityInfo,
+			Message:  "Wrapped a $ref in allOf, as keywords next to $ref are ignored in 3.0",
+			Before:   before,
+			After:    nodeValue(node),
+		})
+	})
+}
+
+// find30AllOfReferences finds schema that only wrap a single `$ref` in `allOf`.
+func find30AllOfReferences(model *libopenapi.DocumentModel[v3.Document]) []string {
+	var pointers []string
+
+	updateAllSchema(model, func(schema *base.Schema, pointer string) {
+		if len(schema.AllOf) == 1 && schema.AllOf[0] != nil && schema.AllOf[0].IsReference() {
+			pointers = append(pointers, pointer)
+		}
+	})
+
+	return pointers
+}
+
+// collapse30AllOfReferencesTo31 replaces single `$ref` allOf wrappers in a rendered 3.1 document with `$ref`.
+//
+// Keywords next to `$ref` apply in 3.1, so the wrapper is no longer needed.
+func collapse30AllOfReferencesTo31(data []byte, pointers []string, report *Report) ([]byte, error) {
+	return patchDocument(data, pointers, func(node *yaml.Node, pointer string) {
+		before := nodeValue(node)
+		collapseAllOfReference(node)
+
+		report.Add(Diagnostic{
+			Pointer:  pointer,
+			Rule:     "all-of-to-ref-siblings",
+			Severity: SeverityInfo,
+			Message:  "Replaced an allOf with a single $ref with $ref, as keywords next to $ref apply in 3.1",
+			Before:   before,
+			After:    nodeValue(node),
+		})
+	})
+}
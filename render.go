@@ -0,0 +1,86 @@
+package openapispecconverter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/pb33f/libopenapi"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// renderDocument renders a document after the model has been changed.
+//
+// libopenapi drops `explode: false` from encodings when rendering, which
+// changes how form fields are serialized, so we add those values back in.
+func renderDocument(doc libopenapi.Document, model *libopenapi.DocumentModel[v3.Document]) ([]byte, error) {
+	var unexploded []string
+
+	updateAllEncodings(model, func(encoding *v3.Encoding, pointer string) {
+		if encoding.Explode != nil && !*encoding.Explode {
+			unexploded = append(unexploded, pointer)
+		}
+	})
+
+	data, _, _, errs := doc.RenderAndReload()
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return patchDocument(data, unexploded, func(encoding *yaml.Node, pointer string) {
+		encoding.Style = 0
+		setMappingValue(encoding, "explode", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "false"})
+	})
+}
+
+// patchDocument calls `patch` with the node at each JSON pointer in a document,
+// and renders the document again as YAML.
+//
+// The data is returned as-is if there are no pointers to patch.
+func patchDocument(data []byte, pointers []string, patch func(node *yaml.Node, pointer string)) ([]byte, error) {
+	if len(pointers) == 0 {
+		return data, nil
+	}
+
+	var root yaml.Node
+
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("Error loading rendered document: %w", err)
+	}
+
+	// Render JSON documents as plain YAML, like other documents.
+	if DetectFormat(data) == JSON {
+		clearStyle(&root)
+	}
+
+	for _, pointer := range pointers {
+		node, err := findPointer(&root, pointer)
+
+		if err != nil {
+			return nil, fmt.Errorf("Error updating %s: %w", pointer, err)
+		}
+
+		patch(node, pointer)
+	}
+
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(&root); err != nil {
+		return nil, fmt.Errorf("Error rendering document: %w", err)
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// clearStyle clears the style of a node and every node in it, so they are rendered in the default style.
+func clearStyle(node *yaml.Node) {
+	node.Style = 0
+
+	for _, child := range node.Content {
+		clearStyle(child)
+	}
+}
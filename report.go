@@ -0,0 +1,229 @@
+package openapispecconverter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Severity describes how much information a conversion step lost.
+type Severity int
+
+const (
+	// SeverityInfo is used for rewrites that keep the same meaning.
+	SeverityInfo Severity = iota
+	// SeverityWarning is used when information is changed or dropped.
+	SeverityWarning
+	// SeverityError is used when the output cannot represent the input.
+	SeverityError
+)
+
+func (severity Severity) String() string {
+	switch severity {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return fmt.Sprintf("Severity(%d)", int(severity))
+	}
+}
+
+// ParseSeverity parses a severity name: info, warning, or error
+func ParseSeverity(name string) (Severity, error) {
+	switch strings.ToLower(name) {
+	case "info":
+		return SeverityInfo, nil
+	case "warning":
+		return SeverityWarning, nil
+	case "error":
+		return SeverityError, nil
+	default:
+		return 0, fmt.Errorf("Invalid severity: %s", name)
+	}
+}
+
+func (severity Severity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(severity.String())
+}
+
+// Diagnostic describes a single change made to a document during conversion.
+type Diagnostic struct {
+	// Pointer is a JSON pointer to the location of the change in the input document.
+	Pointer  string   `json:"pointer"`
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	Before   any      `json:"before,omitempty"`
+	After    any      `json:"after,omitempty"`
+}
+
+// Report collects diagnostics from every conversion step.
+//
+// A nil *Report can be used to ignore diagnostics.
+type Report struct {
+	Diagnostics []Diagnostic
+}
+
+// Add records a diagnostic in the report.
+func (report *Report) Add(diagnostic Diagnostic) {
+	if report != nil {
+		report.Diagnostics = append(report.Diagnostics, diagnostic)
+	}
+}
+
+// HasSeverity returns true if any diagnostic is at least as severe as the given severity.
+func (report *Report) HasSeverity(severity Severity) bool {
+	if report == nil {
+		return false
+	}
+
+	for _, diagnostic := range report.Diagnostics {
+		if diagnostic.Severity >= severity {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MarshalJSON renders the report as a JSON list of diagnostics.
+func (report *Report) MarshalJSON() ([]byte, error) {
+	if report == nil || report.Diagnostics == nil {
+		return []byte("[]"), nil
+	}
+
+	return json.Marshal(report.Diagnostics)
+}
+
+// MarshalSARIF renders the report as a SARIF 2.1.0 log.
+//
+// If artifactURI is not empty, every result will point to it as the analyzed file.
+func (report *Report) MarshalSARIF(artifactURI string) ([]byte, error) {
+	type Message struct {
+		Text string `json:"text"`
+	}
+	type ArtifactLocation struct {
+		URI string `json:"uri"`
+	}
+	type PhysicalLocation struct {
+		ArtifactLocation ArtifactLocation `json:"artifactLocation"`
+	}
+	type LogicalLocation struct {
+		FullyQualifiedName string `json:"fullyQualifiedName"`
+		Kind               string `json:"kind"`
+	}
+	type Location struct {
+		PhysicalLocation *PhysicalLocation `json:"physicalLocation,omitempty"`
+		LogicalLocations []LogicalLocation `json:"logicalLocations"`
+	}
+	type Result struct {
+		RuleID     string         `json:"ruleId"`
+		Level      string         `json:"level"`
+		Message    Message        `json:"message"`
+		Locations  []Location     `json:"locations"`
+		Properties map[string]any `json:"properties,omitempty"`
+	}
+	type Rule struct {
+		ID string `json:"id"`
+	}
+	type Driver struct {
+		Name           string `json:"name"`
+		InformationURI string `json:"informationUri"`
+		Rules          []Rule `json:"rules"`
+	}
+	type Tool struct {
+		Driver Driver `json:"driver"`
+	}
+	type Run struct {
+		Tool    Tool     `json:"tool"`
+		Results []Result `json:"results"`
+	}
+	type Log struct {
+		Schema  string `json:"$schema"`
+		Version string `json:"version"`
+		Runs    []Run  `json:"runs"`
+	}
+
+	run := Run{
+		Tool: Tool{
+			Driver: Driver{
+				Name:           "openapi-spec-converter",
+				InformationURI: "https://github.com/dense-analysis/openapi-spec-converter",
+				Rules:          []Rule{},
+			},
+		},
+		Results: []Result{},
+	}
+	seenRules := map[string]bool{}
+
+	if report != nil {
+		for _, diagnostic := range report.Diagnostics {
+			if !seenRules[diagnostic.Rule] {
+				seenRules[diagnostic.Rule] = true
+				run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, Rule{ID: diagnostic.Rule})
+			}
+
+			level := "note"
+
+			switch diagnostic.Severity {
+			case SeverityWarning:
+				level = "warning"
+			case SeverityError:
+				level = "error"
+			}
+
+			location := Location{
+				LogicalLocations: []LogicalLocation{
+					{FullyQualifiedName: diagnostic.Pointer, Kind: "member"},
+				},
+			}
+
+			if len(artifactURI) > 0 {
+				location.PhysicalLocation = &PhysicalLocation{
+					ArtifactLocation: ArtifactLocation{URI: artifactURI},
+				}
+			}
+
+			result := Result{
+				RuleID:    diagnostic.Rule,
+				Level:     level,
+				Message:   Message{Text: diagnostic.Message},
+				Locations: []Location{location},
+			}
+
+			if diagnostic.Before != nil || diagnostic.After != nil {
+				result.Properties = map[string]any{
+					"before": diagnostic.Before,
+					"after":  diagnostic.After,
+				}
+			}
+
+			run.Results = append(run.Results, result)
+		}
+	}
+
+	return json.MarshalIndent(Log{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []Run{run},
+	}, "", "  ")
+}
+
+// joinPointer appends unescaped reference tokens to a JSON pointer.
+func joinPointer(pointer string, tokens ...string) string {
+	var builder strings.Builder
+
+	builder.WriteString(pointer)
+
+	for _, token := range tokens {
+		token = strings.ReplaceAll(token, "~", "~0")
+		token = strings.ReplaceAll(token, "/", "~1")
+		builder.WriteString("/")
+		builder.WriteString(token)
+	}
+
+	return builder.String()
+}
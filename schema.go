@@ -0,0 +1,543 @@
+package openapispecconverter
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	"github.com/pb33f/libopenapi/orderedmap"
+	"gopkg.in/yaml.v3"
+)
+
+// nodeValue decodes a YAML node for showing the value in a diagnostic.
+func nodeValue(node *yaml.Node) any {
+	var value any
+
+	if err := node.Decode(&value); err != nil {
+		return node.Value
+	}
+
+	return value
+}
+
+func make30RequiredAndReadonlyPropertiesOnlyReadonly(schema *base.Schema, pointer string, report *Report) {
+	if schema.Properties != nil && len(schema.Required) > 0 {
+		newRequired := []string{}
+
+		for _, propName := range schema.Required {
+			readonly := false
+
+			if schema.Properties != nil {
+				if item, ok := schema.Properties.Get(propName); ok {
+					propSchema := item.Schema()
+
+					readonly = propSchema.ReadOnly != nil && *propSchema.ReadOnly
+				}
+			}
+
+			if !readonly {
+				newRequired = append(newRequired, propName)
+			} else {
+				report.Add(Diagnostic{
+					Pointer:  joinPointer(pointer, "required"),
+					Rule:     "required-readonly-property",
+					Severity: SeverityWarning,
+					Message:  fmt.Sprintf("Removed readOnly property %q from required", propName),
+					Before:   schema.Required,
+				})
+			}
+		}
+
+		schema.Required = newRequired
+	}
+}
+
+func convert30NullablesTo31TypeArrays(schema *base.Schema, pointer string, report *Report) {
+	// Replace {type: T, nullable: true} with {type: [T, "null"]}, etc.
+	if schema.Nullable != nil {
+		if *schema.Nullable {
+			before := slices.Clone(schema.Type)
+			schema.Type = append(schema.Type, "null")
+
+			report.Add(Diagnostic{
+				Pointer:  joinPointer(pointer, "nullable"),
+				Rule:     "nullable-to-type-array",
+				Severity: SeverityInfo,
+				Message:  "Replaced nullable with a \"null\" type",
+				Before:   map[string]any{"type": before, "nullable": true},
+				After:    map[string]any{"type": schema.Type},
+			})
+		}
+
+		schema.Nullable = nil
+	}
+}
+
+func convert31TypeArraysTo30(schema *base.Schema, pointer string, report *Report) {
+	nullable := false
+	nonNullType := ""
+
+	for _, value := range schema.Type {
+		if value == "null" {
+			nullable = true
+		} else {
+			nonNullType = value
+		}
+	}
+
+	if nullable && len(schema.Type) == 2 {
+		// In case of {type: [T, "null"]} set {type: T, nullable: true}
+		report.Add(Diagnostic{
+			Pointer:  joinPointer(pointer, "type"),
+			Rule:     "type-array-to-nullable",
+			Severity: SeverityInfo,
+			Message:  "Replaced a \"null\" type with nullable",
+			Before:   map[string]any{"type": slices.Clone(schema.Type)},
+			After:    map[string]any{"type": nonNullType, "nullable": true},
+		})
+
+		schema.Type[0] = nonNullType
+		schema.Type = schema.Type[:1]
+		schema.Nullable = &nullable
+	} else if len(schema.Type) >= 2 {
+		// In case of 2 or more non-null values, set them in oneOf
+		// if "null" was one of the values then all values will be nullable.
+		before := map[string]any{"type": slices.Clone(schema.Type)}
+		after := []map[string]any{}
+		severity := SeverityWarning
+
+		if len(schema.OneOf) > 0 {
+			// Existing oneOf schemas will be replaced.
+			severity = SeverityError
+			before["oneOf"] = len(schema.OneOf)
+		}
+
+		schema.OneOf = make([]*base.SchemaProxy, 0, len(schema.Type))
+
+		for _, value := range schema.Type {
+			if value != "null" {
+				newSchema := base.Schema{Type: []string{value}}
+
+				if nullable {
+					newSchema.Nullable = &nullable
+				}
+
+				schema.OneOf = append(schema.OneOf, base.CreateSchemaProxy(&newSchema))
+				after = append(after, map[string]any{"type": value, "nullable": nullable})
+			}
+		}
+
+		report.Add(Diagnostic{
+			Pointer:  joinPointer(pointer, "type"),
+			Rule:     "type-array-to-one-of",
+			Severity: severity,
+			Message:  "Replaced a type array with oneOf, keywords for specific types now apply to every type",
+			Before:   before,
+			After:    map[string]any{"oneOf": after},
+		})
+
+		// Clear the type field.
+		schema.Type = nil
+	}
+}
+
+func convert30MinMaxTo31(schema *base.Schema, pointer string, report *Report) {
+	convert30ExclusiveBoundTo31 := func(
+		boundName string,
+		exclusiveBoundName string,
+		bound **float64,
+		exclusiveBound **base.DynamicValue[bool, float64],
+	) {
+		if *exclusiveBound != nil && (*exclusiveBound).IsA() {
+			if (*exclusiveBound).A {
+				// Before: {miniumum: val, exclusiveMinimum: true}
+				// After: {exclusiveMinimum: val}
+				if *bound != nil {
+					(*exclusiveBound).N = 1
+					(*exclusiveBound).B = **bound
+
+					report.Add(Diagnostic{
+						Pointer:  joinPointer(pointer, exclusiveBoundName),
+						Rule:     "exclusive-bound-to-number",
+						Severity: SeverityInfo,
+						Message:  fmt.Sprintf("Moved %s into %s", boundName, exclusiveBoundName),
+						Before:   map[string]any{boundName: **bound, exclusiveBoundName: true},
+						After:    map[string]any{exclusiveBoundName: **bound},
+					})
+				}
+
+				*bound = nil
+			} else {
+				// Before: {minimum: val, exclusiveMinimum: false}
+				// After: {minimum: val}
+				*exclusiveBound = nil
+			}
+		}
+	}
+
+	convert30ExclusiveBoundTo31("minimum", "exclusiveMinimum", &schema.Minimum, &schema.ExclusiveMinimum)
+	convert30ExclusiveBoundTo31("maximum", "exclusiveMaximum", &schema.Maximum, &schema.ExclusiveMaximum)
+}
+
+func convert31MinMaxTo30(schema *base.Schema, pointer string, report *Report) {
+	convert31ExclusiveBoundTo30 := func(
+		boundName string,
+		exclusiveBoundName string,
+		bound **float64,
+		exclusiveBound **base.DynamicValue[bool, float64],
+	) {
+		if *exclusiveBound != nil && (*exclusiveBound).IsB() {
+			// Before: {exclusiveMinimum: val}
+			// After: {minimum: value, exclusiveMinimum: true}
+			before := map[string]any{exclusiveBoundName: (*exclusiveBound).B}
+			severity := SeverityInfo
+
+			if *bound != nil {
+				// Both bounds can be set in 3.1, but only one fits in 3.0.
+				before[boundName] = **bound
+				severity = SeverityWarning
+			}
+
+			report.Add(Diagnostic{
+				Pointer:  joinPointer(pointer, exclusiveBoundName),
+				Rule:     "exclusive-bound-to-boolean",
+				Severity: severity,
+				Message:  fmt.Sprintf("Moved %s into %s", exclusiveBoundName, boundName),
+				Before:   before,
+				After:    map[string]any{boundName: (*exclusiveBound).B, exclusiveBoundName: true},
+			})
+
+			*bound = &(*exclusiveBound).B
+			(*exclusiveBound).A = true
+			(*exclusiveBound).N = 0
+		}
+	}
+
+	convert31ExclusiveBoundTo30("minimum", "exclusiveMinimum", &schema.Minimum, &schema.ExclusiveMinimum)
+	convert31ExclusiveBoundTo30("maximum", "exclusiveMaximum", &schema.Maximum, &schema.ExclusiveMaximum)
+}
+
+func convert30ExampleTo31Examples(schema *base.Schema, pointer string, report *Report) {
+	if schema.Example != nil {
+		report.Add(Diagnostic{
+			Pointer:  joinPointer(pointer, "example"),
+			Rule:     "example-to-examples",
+			Severity: SeverityInfo,
+			Message:  "Replaced example with examples",
+		})
+
+		schema.Examples = []*yaml.Node{schema.Example}
+		schema.Example = nil
+	}
+}
+
+func convert31ExamplesTo30Example(schema *base.Schema, pointer string, report *Report) {
+	if len(schema.Examples) >= 1 {
+		if len(schema.Examples) > 1 {
+			report.Add(Diagnostic{
+				Pointer:  joinPointer(pointer, "examples"),
+				Rule:     "examples-to-example",
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("Kept the first of %d examples", len(schema.Examples)),
+			})
+		} else {
+			report.Add(Diagnostic{
+				Pointer:  joinPointer(pointer, "examples"),
+				Rule:     "examples-to-example",
+				Severity: SeverityInfo,
+				Message:  "Replaced examples with example",
+			})
+		}
+
+		schema.Example = schema.Examples[0]
+		schema.Examples = nil
+	}
+}
+
+// contentField is a keyword for the content of strings, which libopenapi doesn't render from the high level model.
+type contentField struct {
+	name  string
+	value string
+}
+
+// convert30FormatsTo31ContentFields replaces the formats of binary and base64 encoded strings, and returns the keyword
+// replacing the format, which must be added to the rendered document.
+//
+// Other formats, such as `date-time` or `uuid`, are kept, as 3.1 allows any format.
+func convert30FormatsTo31ContentFields(schema *base.Schema, pointer string, report *Report) *contentField {
+	if len(schema.Type) != 1 || schema.Type[0] != "string" || len(schema.Format) == 0 {
+		return nil
+	}
+
+	var field *contentField
+
+	switch schema.Format {
+	case "binary":
+		field = &contentField{name: "contentMediaType", value: "application/octet-stream"}
+	case "byte", "base64":
+		field = &contentField{name: "contentEncoding", value: "base64"}
+	default:
+		return nil
+	}
+
+	report.Add(Diagnostic{
+		Pointer:  joinPointer(pointer, "format"),
+		Rule:     "format-to-content-fields",
+		Severity: SeverityInfo,
+		Message:  fmt.Sprintf("Replaced string format %q with %s", schema.Format, field.name),
+		Before:   map[string]any{"format": schema.Format},
+		After:    map[string]any{field.name: field.value},
+	})
+
+	schema.Format = ""
+
+	return field
+}
+
+func convert31ContentFieldsTo30Formats(schema *base.Schema, pointer string, report *Report) {
+	if len(schema.Type) == 1 && schema.Type[0] == "string" {
+		lowSchema := schema.GoLow()
+
+		if lowSchema != nil {
+			if len(lowSchema.ContentMediaType.Value) > 0 {
+				if lowSchema.ContentMediaType.Value == "application/octet-stream" {
+					schema.Format = "binary"
+
+					report.Add(Diagnostic{
+						Pointer:  joinPointer(pointer, "contentMediaType"),
+						Rule:     "content-media-type-to-format",
+						Severity: SeverityInfo,
+						Message:  "Replaced contentMediaType with format",
+						Before:   map[string]any{"contentMediaType": lowSchema.ContentMediaType.Value},
+						After:    map[string]any{"format": schema.Format},
+					})
+				} else {
+					report.Add(Diagnostic{
+						Pointer:  joinPointer(pointer, "contentMediaType"),
+						Rule:     "content-media-type-to-format",
+						Severity: SeverityWarning,
+						Message:  "Removed contentMediaType with no equivalent format",
+						Before:   map[string]any{"contentMediaType": lowSchema.ContentMediaType.Value},
+					})
+				}
+
+				lowSchema.ContentMediaType.Mutate("")
+			}
+
+			if len(lowSchema.ContentEncoding.Value) > 0 {
+				if lowSchema.ContentEncoding.Value == "base64" {
+					schema.Format = "base64"
+
+					report.Add(Diagnostic{
+						Pointer:  joinPointer(pointer, "contentEncoding"),
+						Rule:     "content-encoding-to-format",
+						Severity: SeverityInfo,
+						Message:  "Replaced contentEncoding with format",
+						Before:   map[string]any{"contentEncoding": lowSchema.ContentEncoding.Value},
+						After:    map[string]any{"format": schema.Format},
+					})
+				} else {
+					report.Add(Diagnostic{
+						Pointer:  joinPointer(pointer, "contentEncoding"),
+						Rule:     "content-encoding-to-format",
+						Severity: SeverityWarning,
+						Message:  "Removed contentEncoding with no equivalent format",
+						Before:   map[string]any{"contentEncoding": lowSchema.ContentEncoding.Value},
+					})
+				}
+
+				lowSchema.ContentEncoding.Mutate("")
+			}
+		}
+	}
+}
+
+func convert31ConstTo30Enum(schema *base.Schema, pointer string, report *Report) {
+	if schema.Const == nil {
+		return
+	}
+
+	after := map[string]any{"enum": []any{nodeValue(schema.Const)}}
+
+	if len(schema.Enum) > 0 {
+		// const and enum together only allow the const value, if it is in the enum at all.
+		report.Add(Diagnostic{
+			Pointer:  joinPointer(pointer, "const"),
+			Rule:     "const-to-enum",
+			Severity: SeverityWarning,
+			Message:  "Replaced const and enum with a single value enum",
+			Before:   map[string]any{"const": nodeValue(schema.Const), "enum": len(schema.Enum)},
+			After:    after,
+		})
+	} else {
+		report.Add(Diagnostic{
+			Pointer:  joinPointer(pointer, "const"),
+			Rule:     "const-to-enum",
+			Severity: SeverityInfo,
+			Message:  "Replaced const with a single value enum",
+			Before:   map[string]any{"const": nodeValue(schema.Const)},
+			After:    after,
+		})
+	}
+
+	// A null value is only allowed in 3.0 if the schema is also nullable.
+	if schema.Const.Tag == "!!null" {
+		nullable := true
+		schema.Nullable = &nullable
+	}
+
+	schema.Enum = []*yaml.Node{schema.Const}
+	schema.Const = nil
+}
+
+func convert30SingleEnumTo31Const(schema *base.Schema, pointer string, report *Report) {
+	if len(schema.Enum) == 1 && schema.Const == nil {
+		report.Add(Diagnostic{
+			Pointer:  joinPointer(pointer, "enum"),
+			Rule:     "enum-to-const",
+			Severity: SeverityInfo,
+			Message:  "Replaced a single value enum with const",
+			Before:   map[string]any{"enum": []any{nodeValue(schema.Enum[0])}},
+			After:    map[string]any{"const": nodeValue(schema.Enum[0])},
+		})
+
+		schema.Const = schema.Enum[0]
+		schema.Enum = nil
+	}
+}
+
+// prefixItemsExtension keeps 3.1 `prefixItems` when converting to 3.0, which has no tuple arrays.
+const prefixItemsExtension = "x-prefixItems"
+
+// schemaProxyNode renders a schema as a YAML node.
+func schemaProxyNode(schemaProxy *base.SchemaProxy) (*yaml.Node, error) {
+	data, err := schemaProxy.Render()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var node yaml.Node
+
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+
+	return documentRoot(&node), nil
+}
+
+// hasDistinctTypes checks if every schema has a single type that no other schema has,
+// so a value can only ever match one of the schemas.
+func hasDistinctTypes(schemaProxies []*base.SchemaProxy) bool {
+	types := map[string]bool{}
+
+	for _, schemaProxy := range schemaProxies {
+		schema := schemaProxy.Schema()
+
+		if schema == nil || len(schema.Type) != 1 || types[schema.Type[0]] {
+			return false
+		}
+
+		types[schema.Type[0]] = true
+	}
+
+	// Integers are also numbers.
+	return !(types["integer"] && types["number"])
+}
+
+func convert31PrefixItemsTo30Items(schema *base.Schema, pointer string, report *Report) {
+	if len(schema.PrefixItems) == 0 {
+		return
+	}
+
+	prefixItems := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	itemSchemas := make([]*base.SchemaProxy, 0, len(schema.PrefixItems)+1)
+	renderedSchemas := map[string]bool{}
+
+	addItemSchema := func(schemaProxy *base.SchemaProxy, node *yaml.Node) {
+		// Skip schema that are the same as an earlier schema.
+		if data, err := yaml.Marshal(node); err == nil && !renderedSchemas[string(data)] {
+			renderedSchemas[string(data)] = true
+			itemSchemas = append(itemSchemas, schemaProxy)
+		}
+	}
+
+	for _, schemaProxy := range schema.PrefixItems {
+		node, err := schemaProxyNode(schemaProxy)
+
+		if err != nil {
+			// Leave schema we cannot render alone.
+			return
+		}
+
+		prefixItems.Content = append(prefixItems.Content, node)
+		addItemSchema(schemaProxy, node)
+	}
+
+	length := int64(len(schema.PrefixItems))
+	before := map[string]any{"prefixItems": nodeValue(prefixItems)}
+	after := map[string]any{}
+
+	switch {
+	case schema.Items != nil && schema.Items.IsA():
+		// Items after the tuple must match the items schema.
+		if node, err := schemaProxyNode(schema.Items.A); err == nil {
+			before["items"] = nodeValue(node)
+			addItemSchema(schema.Items.A, node)
+		}
+	case schema.Items != nil && !schema.Items.B:
+		// There are no items after the tuple.
+		before["items"] = false
+
+		if schema.MaxItems == nil || *schema.MaxItems > length {
+			schema.MaxItems = &length
+			after["maxItems"] = length
+		}
+	default:
+		// Any items can follow the tuple if items is missing or true.
+		if schema.Items != nil {
+			before["items"] = true
+		}
+
+		addItemSchema(base.CreateSchemaProxy(&base.Schema{}), &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"})
+	}
+
+	if schema.MinItems == nil || *schema.MinItems < length {
+		schema.MinItems = &length
+		after["minItems"] = length
+	}
+
+	if len(itemSchemas) == 1 {
+		schema.Items = &base.DynamicValue[*base.SchemaProxy, bool]{A: itemSchemas[0]}
+	} else if hasDistinctTypes(itemSchemas) {
+		schema.Items = &base.DynamicValue[*base.SchemaProxy, bool]{
+			A: base.CreateSchemaProxy(&base.Schema{OneOf: itemSchemas}),
+		}
+	} else {
+		schema.Items = &base.DynamicValue[*base.SchemaProxy, bool]{
+			A: base.CreateSchemaProxy(&base.Schema{AnyOf: itemSchemas}),
+		}
+	}
+
+	if node, err := schemaProxyNode(schema.Items.A); err == nil {
+		after["items"] = nodeValue(node)
+	}
+
+	after[prefixItemsExtension] = nodeValue(prefixItems)
+
+	report.Add(Diagnostic{
+		Pointer:  joinPointer(pointer, "prefixItems"),
+		Rule:     "prefix-items-to-items",
+		Severity: SeverityWarning,
+		Message:  "Replaced prefixItems with items, the position of each item is no longer checked",
+		Before:   before,
+		After:    after,
+	})
+
+	if schema.Extensions == nil {
+		schema.Extensions = orderedmap.New[string, *yaml.Node]()
+	}
+
+	schema.Extensions.Set(prefixItemsExtension, prefixItems)
+	schema.PrefixItems = nil
+}
@@ -0,0 +1,506 @@
+package openapispecconverter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaDraft is a version of the JSON Schema specification.
+type SchemaDraft int
+
+const (
+	Draft202012 SchemaDraft = iota
+	Draft07
+)
+
+func (draft SchemaDraft) String() string {
+	switch draft {
+	case Draft202012:
+		return "2020-12"
+	case Draft07:
+		return "draft-07"
+	default:
+		return fmt.Sprintf("SchemaDraft(%d)", int(draft))
+	}
+}
+
+// ParseSchemaDraft parses a JSON Schema draft name: 2020-12 or draft-07
+func ParseSchemaDraft(name string) (SchemaDraft, error) {
+	switch strings.ToLower(name) {
+	case "2020-12":
+		return Draft202012, nil
+	case "draft-07", "07":
+		return Draft07, nil
+	default:
+		return 0, fmt.Errorf("Invalid JSON Schema draft: %s", name)
+	}
+}
+
+// metaSchema returns the `$schema` URI for a draft.
+func (draft SchemaDraft) metaSchema() string {
+	if draft == Draft07 {
+		return "http://json-schema.org/draft-07/schema#"
+	}
+
+	return "https://json-schema.org/draft/2020-12/schema"
+}
+
+// definitionsKeyword returns the keyword for reusable schemas in a draft.
+func (draft SchemaDraft) definitionsKeyword() string {
+	if draft == Draft07 {
+		return "definitions"
+	}
+
+	return "$defs"
+}
+
+// SchemaExportMode sets how component schemas are written as JSON Schema.
+type SchemaExportMode int
+
+const (
+	// SchemaExportBundle writes every schema into a single file in `$defs`.
+	SchemaExportBundle SchemaExportMode = iota
+	// SchemaExportFiles writes every schema into its own file, referencing each other with relative paths.
+	SchemaExportFiles
+)
+
+// SchemaBundleFilename is the filename for schemas exported with SchemaExportBundle.
+const SchemaBundleFilename = "schemas.json"
+
+// Keywords for subschemas in JSON Schema, which are walked through to find every schema.
+var (
+	schemaMapKeywords = []string{
+		"properties", "patternProperties", "dependentSchemas", "$defs", "definitions", "dependencies",
+	}
+	schemaValueKeywords = []string{
+		"items", "additionalItems", "additionalProperties", "unevaluatedProperties", "unevaluatedItems",
+		"contains", "propertyNames", "not", "if", "then", "else",
+	}
+	schemaListKeywords = []string{"prefixItems", "allOf", "anyOf", "oneOf"}
+)
+
+// walkSchemaNode calls `callback` for a schema and every schema in it.
+//
+// Nested schema are visited before the schema containing them.
+func walkSchemaNode(node *yaml.Node, pointer string, callback func(node *yaml.Node, pointer string)) {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return
+	}
+
+	for _, keyword := range schemaMapKeywords {
+		if schemaMap := mappingValue(node, keyword); schemaMap != nil && schemaMap.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(schemaMap.Content); i += 2 {
+				walkSchemaNode(schemaMap.Content[i+1], joinPointer(pointer, keyword, schemaMap.Content[i].Value), callback)
+			}
+		}
+	}
+
+	for _, keyword := range schemaValueKeywords {
+		value := mappingValue(node, keyword)
+
+		// Draft-07 `items` can be a list of schema.
+		if value != nil && value.Kind == yaml.SequenceNode {
+			for i, schema := range value.Content {
+				walkSchemaNode(schema, joinPointer(pointer, keyword, strconv.Itoa(i)), callback)
+			}
+		} else {
+			walkSchemaNode(value, joinPointer(pointer, keyword), callback)
+		}
+	}
+
+	for _, keyword := range schemaListKeywords {
+		if schemaList := mappingValue(node, keyword); schemaList != nil && schemaList.Kind == yaml.SequenceNode {
+			for i, schema := range schemaList.Content {
+				walkSchemaNode(schema, joinPointer(pointer, keyword, strconv.Itoa(i)), callback)
+			}
+		}
+	}
+
+	callback(node, pointer)
+}
+
+// removeMappingKey removes a key from a YAML mapping node, and returns the value for the key.
+func removeMappingKey(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			value := node.Content[i+1]
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+
+			return value
+		}
+	}
+
+	return nil
+}
+
+// openAPISchemaKeywords are keywords from the OpenAPI vocabulary with no meaning in JSON Schema.
+var openAPISchemaKeywords = []string{"discriminator", "xml", "externalDocs"}
+
+// removeOpenAPISchemaKeywords removes OpenAPI keywords and extensions from a schema,
+// and replaces `example` with `examples`, so the schema is plain JSON Schema.
+func removeOpenAPISchemaKeywords(node *yaml.Node, pointer string, report *Report) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+
+	if example := removeMappingKey(node, "example"); example != nil {
+		if mappingValue(node, "examples") == nil {
+			setMappingValue(node, "examples", &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: []*yaml.Node{example}})
+
+			report.Add(Diagnostic{
+				Pointer:  joinPointer(pointer, "example"),
+				Rule:     "example-to-examples",
+				Severity: SeverityInfo,
+				Message:  "Replaced example with examples",
+			})
+		} else {
+			report.Add(Diagnostic{
+				Pointer:  joinPointer(pointer, "example"),
+				Rule:     "example-removed",
+				Severity: SeverityInfo,
+				Message:  "Removed example, as the schema already has examples",
+				Before:   nodeValue(example),
+			})
+		}
+	}
+
+	for _, keyword := range openAPISchemaKeywords {
+		if value := removeMappingKey(node, keyword); value != nil {
+			report.Add(Diagnostic{
+				Pointer:  joinPointer(pointer, keyword),
+				Rule:     "keyword-removed",
+				Severity: SeverityWarning,
+				Message:  "Removed " + keyword + ", which is only supported in OpenAPI",
+				Before:   nodeValue(value),
+			})
+		}
+	}
+
+	for _, key := range mappingKeys(node) {
+		if strings.HasPrefix(key, "x-") {
+			report.Add(Diagnostic{
+				Pointer:  joinPointer(pointer, key),
+				Rule:     "extension-removed",
+				Severity: SeverityInfo,
+				Message:  "Removed " + key + ", as extensions are only supported in OpenAPI",
+				Before:   nodeValue(removeMappingKey(node, key)),
+			})
+		}
+	}
+}
+
+// draft07UnsupportedKeywords are 2020-12 keywords with no equivalent in draft-07.
+var draft07UnsupportedKeywords = []string{
+	"unevaluatedProperties", "unevaluatedItems", "minContains", "maxContains",
+	"$anchor", "$dynamicAnchor", "$dynamicRef",
+}
+
+// convert202012SchemaToDraft07 replaces 2020-12 keywords in a schema with their draft-07 equivalents.
+func convert202012SchemaToDraft07(node *yaml.Node, pointer string, report *Report) {
+	// Draft-07 ignores keywords next to `$ref`.
+	if mappingValue(node, "$ref") != nil && len(node.Content) > 2 {
+		before := nodeValue(node)
+		wrapReferenceInAllOf(node)
+
+		report.Add(Diagnostic{
+			Pointer:  pointer,
+			Rule:     "ref-siblings-to-all-of",
+			Severity: SeverityInfo,
+			Message:  "Wrapped a $ref in allOf, as keywords next to $ref are ignored in draft-07",
+			Before:   before,
+			After:    nodeValue(node),
+		})
+	}
+
+	if renameMappingKey(node, "$defs", "definitions") != nil {
+		report.Add(Diagnostic{
+			Pointer:  joinPointer(pointer, "$defs"),
+			Rule:     "defs-to-definitions",
+			Severity: SeverityInfo,
+			Message:  "Replaced $defs with definitions",
+		})
+	}
+
+	// A list of schema for `items` checks each item by position in draft-07,
+	// and `additionalItems` checks every item after them.
+	if prefixItems := mappingValue(node, "prefixItems"); prefixItems != nil {
+		before := map[string]any{"prefixItems": nodeValue(prefixItems)}
+
+		if items := removeMappingKey(node, "items"); items != nil {
+			before["items"] = nodeValue(items)
+			setMappingValue(node, "additionalItems", items)
+		}
+
+		renameMappingKey(node, "prefixItems", "items")
+
+		report.Add(Diagnostic{
+			Pointer:  joinPointer(pointer, "prefixItems"),
+			Rule:     "prefix-items-to-items",
+			Severity: SeverityInfo,
+			Message:  "Replaced prefixItems with a list of items",
+			Before:   before,
+		})
+	}
+
+	dependentSchemas := removeMappingKey(node, "dependentSchemas")
+	dependentRequired := removeMappingKey(node, "dependentRequired")
+
+	if dependentSchemas != nil || dependentRequired != nil {
+		dependencies := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+
+		for _, dependent := range []*yaml.Node{dependentSchemas, dependentRequired} {
+			if dependent != nil && dependent.Kind == yaml.MappingNode {
+				dependencies.Content = append(dependencies.Content, dependent.Content...)
+			}
+		}
+
+		setMappingValue(node, "dependencies", dependencies)
+
+		report.Add(Diagnostic{
+			Pointer:  pointer,
+			Rule:     "dependent-to-dependencies",
+			Severity: SeverityInfo,
+			Message:  "Replaced dependentSchemas and dependentRequired with dependencies",
+			After:    nodeValue(dependencies),
+		})
+	}
+
+	for _, keyword := range draft07UnsupportedKeywords {
+		if value := removeMappingKey(node, keyword); value != nil {
+			report.Add(Diagnostic{
+				Pointer:  joinPointer(pointer, keyword),
+				Rule:     "keyword-removed",
+				Severity: SeverityWarning,
+				Message:  "Removed " + keyword + ", which is not supported in draft-07",
+				Before:   nodeValue(value),
+			})
+		}
+	}
+}
+
+// exportSchemaReference rewrites a `$ref` to a component schema for an exported schema.
+func exportSchemaReference(ref string, draft SchemaDraft, mode SchemaExportMode) string {
+	name, ok := strings.CutPrefix(ref, "#/components/schemas/")
+
+	if !ok {
+		return ref
+	}
+
+	rest := ""
+
+	if i := strings.Index(name, "/"); i >= 0 {
+		name, rest = name[:i], name[i:]
+	}
+
+	// Keywords in the path to a nested schema are renamed in draft-07.
+	if draft == Draft07 {
+		rest = strings.NewReplacer("/$defs/", "/definitions/", "/prefixItems/", "/items/").Replace(rest)
+	}
+
+	// Component names can only contain letters, digits, `.`, `-`, and `_`, so they can be used as filenames.
+	if mode == SchemaExportFiles {
+		if len(rest) > 0 {
+			return name + ".json#" + rest
+		}
+
+		return name + ".json"
+	}
+
+	return "#/" + draft.definitionsKeyword() + "/" + name + rest
+}
+
+// writeJSONNode writes a YAML node as compact JSON, keeping the order of keys in mappings.
+func writeJSONNode(buffer *bytes.Buffer, node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			buffer.WriteString("null")
+
+			return nil
+		}
+
+		return writeJSONNode(buffer, node.Content[0])
+	case yaml.AliasNode:
+		return writeJSONNode(buffer, node.Alias)
+	case yaml.MappingNode:
+		buffer.WriteByte('{')
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if i > 0 {
+				buffer.WriteByte(',')
+			}
+
+			if err := writeJSONValue(buffer, node.Content[i].Value); err != nil {
+				return err
+			}
+
+			buffer.WriteByte(':')
+
+			if err := writeJSONNode(buffer, node.Content[i+1]); err != nil {
+				return err
+			}
+		}
+
+		buffer.WriteByte('}')
+	case yaml.SequenceNode:
+		buffer.WriteByte('[')
+
+		for i, item := range node.Content {
+			if i > 0 {
+				buffer.WriteByte(',')
+			}
+
+			if err := writeJSONNode(buffer, item); err != nil {
+				return err
+			}
+		}
+
+		buffer.WriteByte(']')
+	default:
+		var value any
+
+		if err := node.Decode(&value); err != nil {
+			return err
+		}
+
+		return writeJSONValue(buffer, value)
+	}
+
+	return nil
+}
+
+// writeJSONValue writes a value as compact JSON without escaping HTML characters.
+func writeJSONValue(buffer *bytes.Buffer, value any) error {
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(value); err != nil {
+		return err
+	}
+
+	// Encode always adds a newline after the value.
+	buffer.Truncate(buffer.Len() - 1)
+
+	return nil
+}
+
+// renderJSONFile renders a YAML node as an indented JSON file.
+//
+// The node is written directly, instead of rendered as YAML first, so strings such as `y` are kept as strings,
+// and properties keep their order.
+func renderJSONFile(node *yaml.Node) ([]byte, error) {
+	var compact bytes.Buffer
+
+	if err := writeJSONNode(&compact, node); err != nil {
+		return nil, err
+	}
+
+	var buffer bytes.Buffer
+
+	if err := json.Indent(&buffer, compact.Bytes(), "", "  "); err != nil {
+		return nil, err
+	}
+
+	buffer.WriteByte('\n')
+
+	return buffer.Bytes(), nil
+}
+
+// ExportSchemas converts a document of any version to 3.1, and exports its component schemas as JSON Schema.
+//
+// The files to write are returned by filename. SchemaExportBundle returns a single SchemaBundleFilename file,
+// and SchemaExportFiles returns a file for each schema named after the schema.
+func ExportSchemas(data []byte, draft SchemaDraft, mode SchemaExportMode, report *Report) (map[string][]byte, error) {
+	data, err := convertDocument(data, OpenAPI31, report, conversionOptions{})
+
+	if err != nil {
+		return nil, err
+	}
+
+	var root yaml.Node
+
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, &ParseError{Err: err}
+	}
+
+	schemas := mappingValue(mappingValue(documentRoot(&root), "components"), "schemas")
+	schemaNodes := map[string]*yaml.Node{}
+	var names []string
+
+	if schemas != nil && schemas.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(schemas.Content); i += 2 {
+			name := schemas.Content[i].Value
+			schema := schemas.Content[i+1]
+
+			walkSchemaNode(schema, joinPointer("/components/schemas", name), func(node *yaml.Node, pointer string) {
+				if ref := mappingValue(node, "$ref"); ref != nil && ref.Kind == yaml.ScalarNode {
+					ref.Value = exportSchemaReference(ref.Value, draft, mode)
+				}
+
+				removeOpenAPISchemaKeywords(node, pointer, report)
+
+				if draft == Draft07 {
+					convert202012SchemaToDraft07(node, pointer, report)
+				}
+			})
+
+			names = append(names, name)
+			schemaNodes[name] = schema
+		}
+	}
+
+	metaSchema := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: draft.metaSchema()}
+	files := map[string][]byte{}
+
+	if mode == SchemaExportFiles {
+		for _, name := range names {
+			schema := schemaNodes[name]
+
+			// Boolean schema can't have `$schema` set.
+			if schema.Kind == yaml.MappingNode {
+				schema.Content = append(
+					[]*yaml.Node{{Kind: yaml.ScalarNode, Tag: "!!str", Value: "$schema"}, metaSchema},
+					schema.Content...,
+				)
+			}
+
+			if files[name+".json"], err = renderJSONFile(schema); err != nil {
+				return nil, fmt.Errorf("Error rendering schema %s: %w", name, err)
+			}
+		}
+
+		return files, nil
+	}
+
+	definitions := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+
+	for _, name := range names {
+		definitions.Content = append(
+			definitions.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name},
+			schemaNodes[name],
+		)
+	}
+
+	bundle := &yaml.Node{
+		Kind: yaml.MappingNode,
+		Tag:  "!!map",
+		Content: []*yaml.Node{
+			{Kind: yaml.ScalarNode, Tag: "!!str", Value: "$schema"},
+			metaSchema,
+			{Kind: yaml.ScalarNode, Tag: "!!str", Value: draft.definitionsKeyword()},
+			definitions,
+		},
+	}
+
+	if files[SchemaBundleFilename], err = renderJSONFile(bundle); err != nil {
+		return nil, fmt.Errorf("Error rendering schemas: %w", err)
+	}
+
+	return files, nil
+}
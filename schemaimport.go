@@ -0,0 +1,307 @@
+package openapispecconverter
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// componentName replaces characters which cannot be used in component names.
+func componentName(name string) string {
+	return invalidComponentNameCharacters.ReplaceAllString(name, "_")
+}
+
+// schemaFileDraft guesses the JSON Schema draft of a file from `$schema`.
+//
+// Files without `$schema` are read as 2020-12, which OpenAPI 3.1 schemas are based on.
+func schemaFileDraft(root *yaml.Node) string {
+	metaSchema := ""
+
+	if value := mappingValue(root, "$schema"); value != nil {
+		metaSchema = value.Value
+	}
+
+	switch {
+	case strings.Contains(metaSchema, "draft-03"), strings.Contains(metaSchema, "draft-04"):
+		return "draft-04"
+	case strings.Contains(metaSchema, "draft-06"), strings.Contains(metaSchema, "draft-07"):
+		return "draft-07"
+	default:
+		return "2020-12"
+	}
+}
+
+// schemaImporter imports JSON Schema files into component schemas.
+type schemaImporter struct {
+	report *Report
+	// names maps the path of each file to the name of its component schema.
+	names map[string]string
+}
+
+// definitionName returns the component name for a schema in the definitions of a file.
+func (importer *schemaImporter) definitionName(name string, definition string) string {
+	return componentName(name + "." + definition)
+}
+
+// reference rewrites a `$ref` in a file to a reference to a component schema.
+func (importer *schemaImporter) reference(ref string, filePath string) (string, bool) {
+	refPath, fragment, _ := strings.Cut(ref, "#")
+	targetPath := filePath
+
+	if len(refPath) > 0 {
+		if strings.Contains(refPath, "://") {
+			return ref, false
+		}
+
+		targetPath = path.Clean(path.Join(path.Dir(filePath), refPath))
+	}
+
+	name, ok := importer.names[targetPath]
+
+	if !ok {
+		return ref, false
+	}
+
+	// Definitions are moved to their own component schemas.
+	for _, keyword := range []string{"/definitions/", "/$defs/"} {
+		if rest, ok := strings.CutPrefix(fragment, keyword); ok {
+			definition, rest, _ := strings.Cut(rest, "/")
+			definition = strings.ReplaceAll(strings.ReplaceAll(definition, "~1", "/"), "~0", "~")
+			fragment = strings.TrimSuffix("/"+rest, "/")
+			name = importer.definitionName(name, definition)
+
+			break
+		}
+	}
+
+	fragment = strings.TrimSuffix(strings.ReplaceAll(fragment, "/definitions/", "/$defs/"), "/")
+
+	return joinPointer("#/components/schemas", name) + fragment, true
+}
+
+// convertSchemaKeywordsTo202012 replaces keywords from earlier JSON Schema drafts with 2020-12 keywords.
+func (importer *schemaImporter) convertSchemaKeywordsTo202012(node *yaml.Node, pointer string, draft string) {
+	// Only the root of a file can set the draft or ID, which don't apply to component schemas.
+	removeMappingKey(node, "$schema")
+	removeMappingKey(node, "$id")
+
+	if draft == "draft-04" {
+		if id := mappingValue(node, "id"); id != nil && id.Kind == yaml.ScalarNode {
+			removeMappingKey(node, "id")
+		}
+
+		// Draft-04 sets exclusive bounds with booleans, which later drafts set with numbers.
+		for _, bound := range [][2]string{{"exclusiveMinimum", "minimum"}, {"exclusiveMaximum", "maximum"}} {
+			exclusive := mappingValue(node, bound[0])
+
+			if exclusive == nil || exclusive.Tag != "!!bool" {
+				continue
+			}
+
+			before := map[string]any{bound[0]: nodeValue(exclusive)}
+			removeMappingKey(node, bound[0])
+
+			if value := mappingValue(node, bound[1]); value != nil && exclusive.Value == "true" {
+				before[bound[1]] = nodeValue(value)
+				removeMappingKey(node, bound[1])
+				setMappingValue(node, bound[0], value)
+			}
+
+			importer.report.Add(Diagnostic{
+				Pointer:  joinPointer(pointer, bound[0]),
+				Rule:     "exclusive-bound-to-number",
+				Severity: SeverityInfo,
+				Message:  "Replaced a draft-04 " + bound[0] + " boolean with a number",
+				Before:   before,
+			})
+		}
+	}
+
+	if draft != "2020-12" {
+		// A list of schema for `items` checks each item by position before 2020-12.
+		if items := mappingValue(node, "items"); items != nil && items.Kind == yaml.SequenceNode {
+			additionalItems := removeMappingKey(node, "additionalItems")
+			renameMappingKey(node, "items", "prefixItems")
+
+			if additionalItems != nil {
+				setMappingValue(node, "items", additionalItems)
+			}
+
+			importer.report.Add(Diagnostic{
+				Pointer:  joinPointer(pointer, "items"),
+				Rule:     "items-to-prefix-items",
+				Severity: SeverityInfo,
+				Message:  "Replaced a list of items with prefixItems",
+			})
+		}
+
+		if dependencies := removeMappingKey(node, "dependencies"); dependencies != nil && dependencies.Kind == yaml.MappingNode {
+			dependentSchemas := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			dependentRequired := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+
+			for i := 0; i+1 < len(dependencies.Content); i += 2 {
+				if dependencies.Content[i+1].Kind == yaml.SequenceNode {
+					dependentRequired.Content = append(dependentRequired.Content, dependencies.Content[i:i+2]...)
+				} else {
+					dependentSchemas.Content = append(dependentSchemas.Content, dependencies.Content[i:i+2]...)
+				}
+			}
+
+			if len(dependentSchemas.Content) > 0 {
+				setMappingValue(node, "dependentSchemas", dependentSchemas)
+			}
+
+			if len(dependentRequired.Content) > 0 {
+				setMappingValue(node, "dependentRequired", dependentRequired)
+			}
+
+			importer.report.Add(Diagnostic{
+				Pointer:  joinPointer(pointer, "dependencies"),
+				Rule:     "dependencies-to-dependent",
+				Severity: SeverityInfo,
+				Message:  "Replaced dependencies with dependentSchemas and dependentRequired",
+				Before:   nodeValue(dependencies),
+			})
+		}
+	}
+
+	renameMappingKey(node, "definitions", "$defs")
+}
+
+// importFile converts the schema in a file and the schemas in its definitions to component schemas.
+func (importer *schemaImporter) importFile(filePath string, root *yaml.Node, schemas *yaml.Node) {
+	name := importer.names[filePath]
+	draft := schemaFileDraft(root)
+	type fileSchema struct {
+		name   string
+		schema *yaml.Node
+	}
+
+	fileSchemas := []fileSchema{{name, root}}
+
+	// Definitions are moved to their own component schemas, so 3.0 and Swagger documents can use them.
+	for _, keyword := range []string{"definitions", "$defs"} {
+		if definitions := removeMappingKey(root, keyword); definitions != nil && definitions.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(definitions.Content); i += 2 {
+				fileSchemas = append(fileSchemas, fileSchema{
+					name:   importer.definitionName(name, definitions.Content[i].Value),
+					schema: definitions.Content[i+1],
+				})
+			}
+		}
+	}
+
+	for _, file := range fileSchemas {
+		schemaName, schema := file.name, file.schema
+		schemaPointer := joinPointer("/components/schemas", schemaName)
+
+		walkSchemaNode(schema, schemaPointer, func(node *yaml.Node, pointer string) {
+			if ref := mappingValue(node, "$ref"); ref != nil && ref.Kind == yaml.ScalarNode {
+				if newRef, ok := importer.reference(ref.Value, filePath); ok {
+					ref.Value = newRef
+				} else {
+					importer.report.Add(Diagnostic{
+						Pointer:  joinPointer(pointer, "$ref"),
+						Rule:     "reference-not-imported",
+						Severity: SeverityWarning,
+						Message:  "Kept a $ref to a schema which was not imported",
+						Before:   ref.Value,
+					})
+				}
+			}
+
+			importer.convertSchemaKeywordsTo202012(node, pointer, draft)
+		})
+
+		if existing := mappingValue(schemas, schemaName); existing != nil {
+			importer.report.Add(Diagnostic{
+				Pointer:  schemaPointer,
+				Rule:     "schema-replaced",
+				Severity: SeverityWarning,
+				Message:  "Replaced a schema in the document with a schema from " + filePath,
+			})
+		}
+
+		setMappingValue(schemas, schemaName, schema)
+	}
+}
+
+// ImportSchemas adds JSON Schema files to the component schemas of a document, and returns the document as 3.1.
+//
+// files maps slash separated file paths to their content, which are used for resolving references between files.
+// Each file is named after the file, and schemas in its definitions are named after the file and the definition.
+// document can be a document of any version to add the schemas to, or nil to create a new document.
+// The result can be converted to any version with a Converter.
+func ImportSchemas(files map[string][]byte, document []byte, report *Report) ([]byte, error) {
+	importer := schemaImporter{
+		report: report,
+		names:  map[string]string{},
+	}
+	filePaths := slices.Sorted(func(yield func(string) bool) {
+		for filePath := range files {
+			if !yield(path.Clean(filePath)) {
+				return
+			}
+		}
+	})
+	roots := map[string]*yaml.Node{}
+	nameFiles := map[string]string{}
+
+	for _, filePath := range filePaths {
+		var root yaml.Node
+
+		if err := yaml.Unmarshal(files[filePath], &root); err != nil {
+			return nil, &ParseError{Err: fmt.Errorf("%s: %w", filePath, err)}
+		}
+
+		if documentRoot(&root).Kind != yaml.MappingNode {
+			return nil, &ParseError{Err: fmt.Errorf("%s: a schema file must contain an object", filePath)}
+		}
+
+		name := componentName(strings.TrimSuffix(path.Base(filePath), path.Ext(filePath)))
+
+		if otherPath, ok := nameFiles[name]; ok {
+			return nil, fmt.Errorf("Schema name %s is used for both %s and %s", name, otherPath, filePath)
+		}
+
+		// Files can be JSON or YAML, so they are rendered in the style of the document.
+		clearStyle(&root)
+
+		roots[filePath] = documentRoot(&root)
+		nameFiles[name] = filePath
+		importer.names[filePath] = name
+	}
+
+	if document == nil {
+		document = []byte(`{"openapi": "3.1.1", "info": {"title": "Schemas", "version": "1.0.0"}, "paths": {}}`)
+	}
+
+	document, err := convertDocument(document, OpenAPI31, report, conversionOptions{})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return patchDocument(document, []string{""}, func(root *yaml.Node, pointer string) {
+		components := mappingValue(root, "components")
+
+		if components == nil {
+			components = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			setMappingValue(root, "components", components)
+		}
+
+		schemas := mappingValue(components, "schemas")
+
+		if schemas == nil {
+			schemas = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			setMappingValue(components, "schemas", schemas)
+		}
+
+		for _, filePath := range filePaths {
+			importer.importFile(filePath, roots[filePath], schemas)
+		}
+	})
+}
@@ -0,0 +1,298 @@
+package openapispecconverter
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/pb33f/libopenapi"
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	"gopkg.in/yaml.v3"
+)
+
+// keywords31RemovedFor30 are 3.1 schema keywords with no equivalent in 3.0, which are removed.
+var keywords31RemovedFor30 = []string{
+	"patternProperties", "propertyNames", "unevaluatedItems", "contains", "minContains", "maxContains",
+	"contentSchema", "$defs", "$id", "$anchor", "$dynamicAnchor", "$dynamicRef", "$schema", "$vocabulary",
+}
+
+// requiredNode returns a schema requiring a property.
+func requiredNode(names ...*yaml.Node) *yaml.Node {
+	return &yaml.Node{
+		Kind: yaml.MappingNode,
+		Tag:  "!!map",
+		Content: []*yaml.Node{
+			scalarNode("!!str", "required"),
+			{Kind: yaml.SequenceNode, Tag: "!!seq", Content: names},
+		},
+	}
+}
+
+// notNode returns a schema matching anything a schema doesn't match.
+func notNode(schema *yaml.Node) *yaml.Node {
+	return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{scalarNode("!!str", "not"), schema}}
+}
+
+// anyOfNode returns a schema matching any of the given schemas.
+func anyOfNode(schemas ...*yaml.Node) *yaml.Node {
+	return &yaml.Node{
+		Kind: yaml.MappingNode,
+		Tag:  "!!map",
+		Content: []*yaml.Node{
+			scalarNode("!!str", "anyOf"),
+			{Kind: yaml.SequenceNode, Tag: "!!seq", Content: schemas},
+		},
+	}
+}
+
+// appendAllOf adds schemas to the `allOf` of a schema, creating it if needed.
+func appendAllOf(node *yaml.Node, schemas ...*yaml.Node) {
+	allOf := mappingValue(node, "allOf")
+
+	if allOf == nil || allOf.Kind != yaml.SequenceNode {
+		allOf = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		setMappingValue(node, "allOf", allOf)
+	}
+
+	allOf.Content = append(allOf.Content, schemas...)
+}
+
+// convert31DependenciesTo30AllOf replaces `dependentRequired` and `dependentSchemas` with `allOf`,
+// where each dependency either doesn't have the property, or matches the dependency.
+func convert31DependenciesTo30AllOf(node *yaml.Node, pointer string, report *Report) {
+	for _, keyword := range []string{"dependentRequired", "dependentSchemas"} {
+		dependencies := mappingValue(node, keyword)
+
+		if dependencies == nil {
+			continue
+		}
+
+		before := nodeValue(dependencies)
+		removeMappingKey(node, keyword)
+
+		if dependencies.Kind != yaml.MappingNode {
+			continue
+		}
+
+		var schemas []*yaml.Node
+
+		for i := 0; i+1 < len(dependencies.Content); i += 2 {
+			name := dependencies.Content[i]
+			dependency := dependencies.Content[i+1]
+
+			if keyword == "dependentRequired" {
+				if dependency.Kind != yaml.SequenceNode {
+					continue
+				}
+
+				dependency = requiredNode(dependency.Content...)
+			}
+
+			schemas = append(schemas, anyOfNode(notNode(requiredNode(copyNode(name))), dependency))
+		}
+
+		appendAllOf(node, schemas...)
+
+		report.Add(Diagnostic{
+			Pointer:  joinPointer(pointer, keyword),
+			Rule:     "dependencies-to-all-of",
+			Severity: SeverityInfo,
+			Message:  "Replaced " + keyword + " with allOf, as 3.0 has no " + keyword,
+			Before:   before,
+			After:    map[string]any{"allOf": nodeValue(&yaml.Node{Kind: yaml.SequenceNode, Content: schemas})},
+		})
+	}
+}
+
+// convert31ConditionalTo30AllOf replaces `if`, `then`, and `else` with `allOf`,
+// where a schema either doesn't match `if` or matches `then`, and either matches `if` or matches `else`.
+func convert31ConditionalTo30AllOf(node *yaml.Node, pointer string, report *Report) {
+	ifSchema := mappingValue(node, "if")
+	thenSchema := mappingValue(node, "then")
+	elseSchema := mappingValue(node, "else")
+
+	if ifSchema == nil && thenSchema == nil && elseSchema == nil {
+		return
+	}
+
+	before := map[string]any{}
+
+	for _, keyword := range []string{"if", "then", "else"} {
+		if value := removeMappingKey(node, keyword); value != nil {
+			before[keyword] = nodeValue(value)
+		}
+	}
+
+	// `then` and `else` are ignored without `if`.
+	if ifSchema == nil {
+		report.Add(Diagnostic{
+			Pointer:  pointer,
+			Rule:     "conditional-removed",
+			Severity: SeverityInfo,
+			Message:  "Removed then and else with no if, which have no effect",
+			Before:   before,
+		})
+
+		return
+	}
+
+	var schemas []*yaml.Node
+
+	if thenSchema != nil {
+		schemas = append(schemas, anyOfNode(notNode(copyNode(ifSchema)), thenSchema))
+	}
+
+	if elseSchema != nil {
+		schemas = append(schemas, anyOfNode(copyNode(ifSchema), elseSchema))
+	}
+
+	appendAllOf(node, schemas...)
+
+	report.Add(Diagnostic{
+		Pointer:  joinPointer(pointer, "if"),
+		Rule:     "conditional-to-all-of",
+		Severity: SeverityInfo,
+		Message:  "Replaced if, then, and else with allOf, as 3.0 has no conditional schemas",
+		Before:   before,
+		After:    map[string]any{"allOf": nodeValue(&yaml.Node{Kind: yaml.SequenceNode, Content: schemas})},
+	})
+}
+
+// convert31UnevaluatedPropertiesTo30 replaces `unevaluatedProperties` with `additionalProperties`,
+// which is the same for schemas with no subschemas that could evaluate properties, and removes it otherwise.
+func convert31UnevaluatedPropertiesTo30(node *yaml.Node, pointer string, report *Report) {
+	unevaluated := mappingValue(node, "unevaluatedProperties")
+
+	if unevaluated == nil {
+		return
+	}
+
+	removeMappingKey(node, "unevaluatedProperties")
+
+	equivalent := !slices.ContainsFunc(
+		[]string{
+			"additionalProperties", "patternProperties", "allOf", "anyOf", "oneOf", "not",
+			"if", "then", "else", "dependentSchemas", "$ref",
+		},
+		func(keyword string) bool { return mappingValue(node, keyword) != nil },
+	)
+
+	if equivalent {
+		setMappingValue(node, "additionalProperties", unevaluated)
+
+		report.Add(Diagnostic{
+			Pointer:  joinPointer(pointer, "unevaluatedProperties"),
+			Rule:     "unevaluated-properties-to-additional-properties",
+			Severity: SeverityInfo,
+			Message:  "Replaced unevaluatedProperties with additionalProperties, as 3.0 has no unevaluatedProperties",
+			Before:   map[string]any{"unevaluatedProperties": nodeValue(unevaluated)},
+			After:    map[string]any{"additionalProperties": nodeValue(unevaluated)},
+		})
+	} else {
+		report.Add(Diagnostic{
+			Pointer:  joinPointer(pointer, "unevaluatedProperties"),
+			Rule:     "keyword-removed",
+			Severity: SeverityWarning,
+			Message:  "Removed unevaluatedProperties, which is not supported in 3.0",
+			Before:   nodeValue(unevaluated),
+		})
+	}
+}
+
+// convert31KeywordsTo30 converts or removes the 3.1 keywords in a schema that 3.0 doesn't have.
+//
+// The pointers of removed keywords are returned, so changes inside them can be left out of the report.
+func convert31KeywordsTo30(node *yaml.Node, pointer string, report *Report) []string {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	// This must run before other keywords are replaced with allOf.
+	convert31UnevaluatedPropertiesTo30(node, pointer, report)
+	convert31DependenciesTo30AllOf(node, pointer, report)
+	convert31ConditionalTo30AllOf(node, pointer, report)
+
+	var removed []string
+
+	if comment := removeMappingKey(node, "$comment"); comment != nil {
+		report.Add(Diagnostic{
+			Pointer:  joinPointer(pointer, "$comment"),
+			Rule:     "comment-removed",
+			Severity: SeverityInfo,
+			Message:  "Removed $comment, which is not supported in 3.0",
+			Before:   nodeValue(comment),
+		})
+	}
+
+	for _, keyword := range keywords31RemovedFor30 {
+		if value := removeMappingKey(node, keyword); value != nil {
+			removed = append(removed, joinPointer(pointer, keyword))
+
+			report.Add(Diagnostic{
+				Pointer:  joinPointer(pointer, keyword),
+				Rule:     "keyword-removed",
+				Severity: SeverityWarning,
+				Message:  "Removed " + keyword + ", which is not supported in 3.0",
+				Before:   nodeValue(value),
+			})
+		}
+	}
+
+	return removed
+}
+
+// convert31SchemaKeywordsTo30 converts or removes 3.1 schema keywords that 3.0 doesn't have in every schema.
+//
+// This is done before the document is loaded for the other conversion steps,
+// as libopenapi loses these keywords, and sometimes the schema containing them, when rendering the document.
+func convert31SchemaKeywordsTo30(data []byte, report *Report) ([]byte, error) {
+	doc, err := libopenapi.NewDocument(data)
+
+	if err != nil {
+		return nil, fmt.Errorf("Error loading document: %w", err)
+	}
+
+	model, errs := doc.BuildV3Model()
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("Errors loading document: %w", errors.Join(errs...))
+	}
+
+	var pointers []string
+
+	// Nested schema come first, so they are converted before their keywords are replaced.
+	updateAllSchema(model, func(schema *base.Schema, pointer string) {
+		pointers = append(pointers, pointer)
+	})
+
+	var changes Report
+	var removed []string
+	visited := map[*yaml.Node]bool{}
+
+	// libopenapi leaves out schemas it can't load, such as those with `unevaluatedItems: false`,
+	// so every schema inside the schemas it finds is walked through as well.
+	data, err = patchDocument(data, pointers, func(node *yaml.Node, pointer string) {
+		walkSchemaNode(node, pointer, func(node *yaml.Node, pointer string) {
+			if !visited[node] {
+				visited[node] = true
+				removed = append(removed, convert31KeywordsTo30(node, pointer, &changes)...)
+			}
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Changes to schemas inside removed keywords are lost with them.
+	for _, diagnostic := range changes.Diagnostics {
+		if !slices.ContainsFunc(removed, func(pointer string) bool {
+			return strings.HasPrefix(diagnostic.Pointer, pointer+"/")
+		}) {
+			report.Add(diagnostic)
+		}
+	}
+
+	return data, nil
+}
@@ -0,0 +1,713 @@
+package openapispecconverter
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/pb33f/libopenapi"
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+const (
+	// securitySchemeNameExtension is set on Swagger definitions split from a 3.x OAuth2 scheme with several flows,
+	// so the flows can be merged back into one scheme.
+	securitySchemeNameExtension = "x-securitySchemeName"
+	// refreshURLExtension holds the refreshUrl of an OAuth2 flow in Swagger, which has no refresh URLs.
+	refreshURLExtension = "x-refreshUrl"
+	// httpSchemeExtension holds the scheme of a 3.x http security scheme converted to a Swagger apiKey.
+	httpSchemeExtension = "x-httpScheme"
+	// bearerFormatExtension holds the bearerFormat of a 3.x http security scheme converted to a Swagger apiKey.
+	bearerFormatExtension = "x-bearerFormat"
+)
+
+// oauthFlowNames are the names of 3.x OAuth2 flows and their Swagger flows,
+// in the order kin-openapi picks a single flow.
+var oauthFlowNames = []struct {
+	name        string
+	swaggerFlow string
+}{
+	{"implicit", "implicit"},
+	{"authorizationCode", "accessCode"},
+	{"password", "password"},
+	{"clientCredentials", "application"},
+}
+
+// oauthFlow returns the field of a 3.x OAuth2 flow by name.
+func oauthFlow(flows *openapi3.OAuthFlows, name string) **openapi3.OAuthFlow {
+	switch name {
+	case "implicit":
+		return &flows.Implicit
+	case "authorizationCode":
+		return &flows.AuthorizationCode
+	case "password":
+		return &flows.Password
+	case "clientCredentials":
+		return &flows.ClientCredentials
+	}
+
+	return nil
+}
+
+// securityExtensions returns a copy of the x- extensions of a security scheme.
+func securityExtensions(extensions map[string]any) map[string]any {
+	result := map[string]any{}
+
+	for name, value := range extensions {
+		if strings.HasPrefix(name, "x-") {
+			result[name] = value
+		}
+	}
+
+	return result
+}
+
+// convertOAuthSchemeToSwagger returns a Swagger definition for every flow of a 3.x OAuth2 scheme.
+func convertOAuthSchemeToSwagger(
+	pointer string,
+	name string,
+	scheme *openapi3.SecurityScheme,
+	report *Report,
+) ([]string, map[string]*openapi2.SecurityScheme) {
+	var flowNames []string
+
+	if scheme.Flows != nil {
+		for _, flowName := range oauthFlowNames {
+			if *oauthFlow(scheme.Flows, flowName.name) != nil {
+				flowNames = append(flowNames, flowName.name)
+			}
+		}
+	}
+
+	var names []string
+	definitions := map[string]*openapi2.SecurityScheme{}
+
+	for _, flowName := range oauthFlowNames {
+		if !slices.Contains(flowNames, flowName.name) {
+			continue
+		}
+
+		flow := *oauthFlow(scheme.Flows, flowName.name)
+		definition := &openapi2.SecurityScheme{
+			Extensions:  securityExtensions(scheme.Extensions),
+			Description: scheme.Description,
+			Type:        "oauth2",
+			Flow:        flowName.swaggerFlow,
+			Scopes:      map[string]string{},
+		}
+
+		maps.Copy(definition.Scopes, flow.Scopes)
+
+		if flowName.name == "implicit" || flowName.name == "authorizationCode" {
+			definition.AuthorizationURL = flow.AuthorizationURL
+		}
+
+		if flowName.name != "implicit" {
+			definition.TokenURL = flow.TokenURL
+		}
+
+		if len(flow.RefreshURL) > 0 {
+			definition.Extensions[refreshURLExtension] = flow.RefreshURL
+
+			report.Add(Diagnostic{
+				Pointer:  joinPointer(pointer, "flows", flowName.name, "refreshUrl"),
+				Rule:     "refresh-url-to-extension",
+				Severity: SeverityInfo,
+				Message:  "Moved refreshUrl to " + refreshURLExtension + ", as Swagger has no refresh URLs",
+				Before:   flow.RefreshURL,
+			})
+		}
+
+		definitionName := name
+
+		// Each flow needs its own definition if there are several flows.
+		if len(flowNames) > 1 {
+			definitionName = name + "_" + flowName.swaggerFlow
+			definition.Extensions[securitySchemeNameExtension] = name
+		}
+
+		if len(definition.Extensions) == 0 {
+			definition.Extensions = nil
+		}
+
+		names = append(names, definitionName)
+		definitions[definitionName] = definition
+	}
+
+	if len(names) > 1 {
+		report.Add(Diagnostic{
+			Pointer:  joinPointer(pointer, "flows"),
+			Rule:     "oauth2-flows-split",
+			Severity: SeverityInfo,
+			Message:  "Split the flows of the scheme into a definition for each flow, as Swagger definitions have one flow",
+			Before:   flowNames,
+			After:    names,
+		})
+	}
+
+	return names, definitions
+}
+
+// convertSecuritySchemeToSwagger returns the Swagger definitions for a 3.x security scheme,
+// which are empty if the scheme cannot be represented in Swagger.
+func convertSecuritySchemeToSwagger(
+	pointer string,
+	name string,
+	scheme *openapi3.SecurityScheme,
+	report *Report,
+) ([]string, map[string]*openapi2.SecurityScheme) {
+	definition := &openapi2.SecurityScheme{
+		Extensions:  securityExtensions(scheme.Extensions),
+		Description: scheme.Description,
+	}
+	removed := scheme.Type + " schemes"
+
+	switch scheme.Type {
+	case "apiKey":
+		if scheme.In == "cookie" {
+			removed = "apiKey schemes in cookies"
+
+			break
+		}
+
+		definition.Type = "apiKey"
+		definition.In = scheme.In
+		definition.Name = scheme.Name
+	case "http":
+		if strings.EqualFold(scheme.Scheme, "basic") {
+			definition.Type = "basic"
+
+			break
+		}
+
+		definition.Type = "apiKey"
+		definition.In = "header"
+		definition.Name = "Authorization"
+		definition.Extensions[httpSchemeExtension] = scheme.Scheme
+		severity := SeverityWarning
+		before := map[string]any{"type": "http", "scheme": scheme.Scheme}
+
+		if len(scheme.BearerFormat) > 0 {
+			definition.Extensions[bearerFormatExtension] = scheme.BearerFormat
+			before["bearerFormat"] = scheme.BearerFormat
+		}
+
+		// A bearer token is sent in the Authorization header like any other API key.
+		if strings.EqualFold(scheme.Scheme, "bearer") {
+			severity = SeverityInfo
+		}
+
+		report.Add(Diagnostic{
+			Pointer:  pointer,
+			Rule:     "http-to-api-key",
+			Severity: severity,
+			Message:  fmt.Sprintf("Replaced the http %s scheme with an apiKey for the Authorization header", scheme.Scheme),
+			Before:   before,
+			After:    map[string]any{"type": "apiKey", "in": "header", "name": "Authorization"},
+		})
+	case "oauth2":
+		if names, definitions := convertOAuthSchemeToSwagger(pointer, name, scheme, report); len(names) > 0 {
+			return names, definitions
+		}
+
+		removed = "oauth2 schemes with no flows"
+	}
+
+	if len(definition.Type) == 0 {
+		report.Add(Diagnostic{
+			Pointer:  pointer,
+			Rule:     "security-scheme-removed",
+			Severity: SeverityWarning,
+			Message:  "Removed the security scheme, as Swagger does not support " + removed,
+			Before:   name,
+		})
+
+		return nil, nil
+	}
+
+	if len(definition.Extensions) == 0 {
+		definition.Extensions = nil
+	}
+
+	return []string{name}, map[string]*openapi2.SecurityScheme{name: definition}
+}
+
+// expandSecurityRequirements replaces the schemes in security requirements with the definitions they were converted to.
+//
+// Requirements using a scheme with several definitions are repeated for each definition,
+// leaving out OAuth2 flows that don't define every required scope, as they could grant tokens with fewer scopes.
+// Requirements using a scheme with no definitions, or with no flows defining the scopes, are removed.
+func expandSecurityRequirements(
+	pointer string,
+	requirements []map[string][]string,
+	definitionNames map[string][]string,
+	definitions map[string]*openapi2.SecurityScheme,
+	report *Report,
+) []map[string][]string {
+	var result []map[string][]string
+
+	for i, requirement := range requirements {
+		expanded := []map[string][]string{{}}
+		requirementPointer := joinPointer(pointer, fmt.Sprint(i))
+		missingScopes := false
+
+		for _, name := range slices.Sorted(maps.Keys(requirement)) {
+			names, ok := definitionNames[name]
+
+			if !ok {
+				names = []string{name}
+			}
+
+			var next []map[string][]string
+
+			for _, definitionName := range names {
+				scopes := requirement[name]
+
+				// A flow split from a scheme can only be used if it defines every required scope.
+				if definition := definitions[definitionName]; ok && definition != nil && definition.Type == "oauth2" {
+					undefined := slices.DeleteFunc(slices.Clone(scopes), func(scope string) bool {
+						_, defined := definition.Scopes[scope]
+
+						return defined
+					})
+
+					if len(undefined) > 0 {
+						report.Add(Diagnostic{
+							Pointer:  joinPointer(requirementPointer, name),
+							Rule:     "security-flow-removed",
+							Severity: SeverityWarning,
+							Message: fmt.Sprintf(
+								"Removed %s from the requirement, as it does not define the scopes %s",
+								definitionName,
+								strings.Join(undefined, ", "),
+							),
+							Before: requirement[name],
+						})
+
+						continue
+					}
+				}
+
+				for _, partial := range expanded {
+					alternative := maps.Clone(partial)
+					alternative[definitionName] = scopes
+					next = append(next, alternative)
+				}
+			}
+
+			// Only blame the scopes if the scheme has flows, but none of them can be used.
+			if len(names) > 0 && len(next) == 0 && len(expanded) > 0 {
+				missingScopes = true
+			}
+
+			expanded = next
+		}
+
+		if len(expanded) == 0 {
+			if missingScopes {
+				report.Add(Diagnostic{
+					Pointer:  requirementPointer,
+					Rule:     "security-requirement-removed",
+					Severity: SeverityError,
+					Message:  "Removed the security requirement, as no flow of its OAuth2 schemes defines every required scope",
+					Before:   requirement,
+				})
+			} else {
+				report.Add(Diagnostic{
+					Pointer:  requirementPointer,
+					Rule:     "security-requirement-removed",
+					Severity: SeverityWarning,
+					Message:  "Removed the security requirement, as it uses a scheme not supported in Swagger",
+					Before:   requirement,
+				})
+			}
+		} else if len(expanded) > 1 {
+			report.Add(Diagnostic{
+				Pointer:  requirementPointer,
+				Rule:     "security-requirement-split",
+				Severity: SeverityInfo,
+				Message:  "Replaced the security requirement with a requirement for each flow of its OAuth2 schemes",
+				Before:   requirement,
+				After:    expanded,
+			})
+		}
+
+		result = append(result, expanded...)
+	}
+
+	if len(requirements) > 0 && len(result) == 0 {
+		report.Add(Diagnostic{
+			Pointer:  pointer,
+			Rule:     "security-requirements-removed",
+			Severity: SeverityError,
+			Message:  removedSecurityMessage(pointer),
+			Before:   requirements,
+		})
+	}
+
+	return result
+}
+
+// removedSecurityMessage describes what happens when every security requirement at a pointer is removed.
+func removedSecurityMessage(pointer string) string {
+	if pointer == "/security" {
+		return "Removed every security requirement, so no security is required"
+	}
+
+	return "Removed every security requirement, so the operation uses the document security"
+}
+
+// convertSecuritySchemesToSwagger sets the security definitions of a Swagger document from 3.x security schemes,
+// and rewrites security requirements for the definitions.
+//
+// kin-openapi only converts one flow of OAuth2 schemes, and fails for schemes not supported in Swagger.
+func convertSecuritySchemesToSwagger(schemes openapi3.SecuritySchemes, swaggerDoc *openapi2.T, report *Report) {
+	if len(schemes) == 0 {
+		return
+	}
+
+	definitionNames := map[string][]string{}
+	swaggerDoc.SecurityDefinitions = map[string]*openapi2.SecurityScheme{}
+
+	for _, name := range slices.Sorted(maps.Keys(schemes)) {
+		schemeRef := schemes[name]
+
+		if schemeRef == nil || schemeRef.Value == nil {
+			continue
+		}
+
+		names, definitions := convertSecuritySchemeToSwagger(
+			joinPointer("", "components", "securitySchemes", name),
+			name,
+			schemeRef.Value,
+			report,
+		)
+
+		if len(names) != 1 || names[0] != name {
+			definitionNames[name] = names
+		}
+
+		maps.Copy(swaggerDoc.SecurityDefinitions, definitions)
+	}
+
+	if len(swaggerDoc.SecurityDefinitions) == 0 {
+		swaggerDoc.SecurityDefinitions = nil
+	}
+
+	if len(definitionNames) == 0 {
+		return
+	}
+
+	if len(swaggerDoc.Security) > 0 {
+		swaggerDoc.Security = expandSecurityRequirements(
+			"/security",
+			swaggerDoc.Security,
+			definitionNames,
+			swaggerDoc.SecurityDefinitions,
+			report,
+		)
+	}
+
+	for _, path := range slices.Sorted(maps.Keys(swaggerDoc.Paths)) {
+		swaggerPathItem := swaggerDoc.Paths[path]
+
+		if swaggerPathItem == nil {
+			continue
+		}
+
+		swaggerOperations := swaggerPathItem.Operations()
+
+		for _, method := range slices.Sorted(maps.Keys(swaggerOperations)) {
+			swaggerOperation := swaggerOperations[method]
+
+			if swaggerOperation.Security == nil || len(*swaggerOperation.Security) == 0 {
+				continue
+			}
+
+			security := openapi2.SecurityRequirements(expandSecurityRequirements(
+				joinPointer("", "paths", path, strings.ToLower(method), "security"),
+				*swaggerOperation.Security,
+				definitionNames,
+				swaggerDoc.SecurityDefinitions,
+				report,
+			))
+
+			// An empty list would make the operation public, so the field is left out if every requirement was removed.
+			if len(security) == 0 {
+				swaggerOperation.Security = nil
+			} else {
+				swaggerOperation.Security = &security
+			}
+		}
+	}
+}
+
+// collapseSecurityRequirements renames schemes in security requirements,
+// and removes requirements that are the same after renaming.
+func collapseSecurityRequirements(requirements openapi3.SecurityRequirements, renamed map[string]string) openapi3.SecurityRequirements {
+	var result openapi3.SecurityRequirements
+
+	for _, requirement := range requirements {
+		collapsed := openapi3.SecurityRequirement{}
+
+		for name, scopes := range requirement {
+			if newName, ok := renamed[name]; ok {
+				name = newName
+			}
+
+			collapsed[name] = scopes
+		}
+
+		if !slices.ContainsFunc(result, func(other openapi3.SecurityRequirement) bool {
+			return maps.EqualFunc(other, collapsed, slices.Equal)
+		}) {
+			result = append(result, collapsed)
+		}
+	}
+
+	return result
+}
+
+// convertSwaggerSecuritySchemeExtensions restores the parts of a 3.x security scheme kept in Swagger extensions.
+func convertSwaggerSecuritySchemeExtensions(pointer string, scheme *openapi3.SecurityScheme, report *Report) {
+	if refreshURL, ok := scheme.Extensions[refreshURLExtension].(string); ok && scheme.Type == "oauth2" &&
+		scheme.Flows != nil {
+		for _, flowName := range oauthFlowNames {
+			if flow := *oauthFlow(scheme.Flows, flowName.name); flow != nil {
+				flow.RefreshURL = refreshURL
+			}
+		}
+
+		delete(scheme.Extensions, refreshURLExtension)
+
+		report.Add(Diagnostic{
+			Pointer:  joinPointer(pointer, refreshURLExtension),
+			Rule:     "x-refresh-url-to-refresh-url",
+			Severity: SeverityInfo,
+			Message:  "Replaced " + refreshURLExtension + " with the refreshUrl of the flow",
+			Before:   refreshURL,
+		})
+	}
+
+	if httpScheme, ok := scheme.Extensions[httpSchemeExtension].(string); ok && scheme.Type == "apiKey" &&
+		scheme.In == "header" && strings.EqualFold(scheme.Name, "Authorization") {
+		scheme.Type = "http"
+		scheme.Scheme = httpScheme
+		scheme.In = ""
+		scheme.Name = ""
+		scheme.BearerFormat, _ = scheme.Extensions[bearerFormatExtension].(string)
+		after := map[string]any{"type": "http", "scheme": httpScheme}
+
+		if len(scheme.BearerFormat) > 0 {
+			after["bearerFormat"] = scheme.BearerFormat
+		}
+
+		delete(scheme.Extensions, httpSchemeExtension)
+		delete(scheme.Extensions, bearerFormatExtension)
+
+		report.Add(Diagnostic{
+			Pointer:  pointer,
+			Rule:     "api-key-to-http",
+			Severity: SeverityInfo,
+			Message:  "Replaced the apiKey for the Authorization header with an http scheme",
+			Before:   map[string]any{"type": "apiKey", "in": "header", "name": "Authorization"},
+			After:    after,
+		})
+	}
+
+	if len(scheme.Extensions) == 0 {
+		scheme.Extensions = nil
+	}
+}
+
+// convertSwaggerSecuritySchemes restores the 3.x security schemes kept in Swagger extensions,
+// and merges definitions split from an OAuth2 scheme with several flows back into one scheme.
+func convertSwaggerSecuritySchemes(swaggerDoc *openapi2.T, doc *openapi3.T, report *Report) {
+	if doc.Components == nil || len(doc.Components.SecuritySchemes) == 0 {
+		return
+	}
+
+	schemes := doc.Components.SecuritySchemes
+	groups := map[string][]string{}
+
+	for _, name := range slices.Sorted(maps.Keys(schemes)) {
+		schemeRef := schemes[name]
+
+		if schemeRef == nil || schemeRef.Ref != "" || schemeRef.Value == nil {
+			continue
+		}
+
+		convertSwaggerSecuritySchemeExtensions(joinPointer("", "securityDefinitions", name), schemeRef.Value, report)
+
+		if schemeName, ok := schemeRef.Value.Extensions[securitySchemeNameExtension].(string); ok &&
+			len(schemeName) > 0 && schemeRef.Value.Type == "oauth2" && schemeRef.Value.Flows != nil {
+			groups[schemeName] = append(groups[schemeName], name)
+		}
+	}
+
+	renamed := map[string]string{}
+
+	for _, schemeName := range slices.Sorted(maps.Keys(groups)) {
+		names := groups[schemeName]
+
+		// Definitions can't be merged into a scheme with the same name that wasn't split.
+		if _, ok := schemes[schemeName]; ok && !slices.Contains(names, schemeName) {
+			continue
+		}
+
+		merged := *schemes[names[0]].Value
+		merged.Extensions = maps.Clone(merged.Extensions)
+		merged.Flows = &openapi3.OAuthFlows{}
+		delete(merged.Extensions, securitySchemeNameExtension)
+
+		if len(merged.Extensions) == 0 {
+			merged.Extensions = nil
+		}
+
+		for _, name := range names {
+			for _, flowName := range oauthFlowNames {
+				if flow := *oauthFlow(schemes[name].Value.Flows, flowName.name); flow != nil {
+					*oauthFlow(merged.Flows, flowName.name) = flow
+				}
+			}
+
+			delete(schemes, name)
+			renamed[name] = schemeName
+		}
+
+		schemes[schemeName] = &openapi3.SecuritySchemeRef{Value: &merged}
+
+		report.Add(Diagnostic{
+			Pointer:  "/securityDefinitions",
+			Rule:     "oauth2-flows-merged",
+			Severity: SeverityInfo,
+			Message:  "Merged definitions split from one OAuth2 scheme into the flows of the scheme",
+			Before:   names,
+			After:    schemeName,
+		})
+	}
+
+	if len(renamed) == 0 {
+		return
+	}
+
+	if len(doc.Security) > 0 {
+		doc.Security = collapseSecurityRequirements(doc.Security, renamed)
+	}
+
+	for _, pathItem := range doc.Paths.Map() {
+		for _, operation := range pathItem.Operations() {
+			if operation.Security != nil && len(*operation.Security) > 0 {
+				security := collapseSecurityRequirements(*operation.Security, renamed)
+				operation.Security = &security
+			}
+		}
+	}
+}
+
+// remove31MutualTLSSchemes removes mutualTLS security schemes and the requirements using them,
+// as 3.0 has no mutualTLS schemes.
+func remove31MutualTLSSchemes(model *libopenapi.DocumentModel[v3.Document], report *Report) {
+	if model.Model.Components == nil || model.Model.Components.SecuritySchemes == nil {
+		return
+	}
+
+	var removed []string
+
+	for name, scheme := range model.Model.Components.SecuritySchemes.FromOldest() {
+		if scheme != nil && scheme.Type == "mutualTLS" {
+			removed = append(removed, name)
+		}
+	}
+
+	if len(removed) == 0 {
+		return
+	}
+
+	for _, name := range removed {
+		model.Model.Components.SecuritySchemes.Delete(name)
+
+		report.Add(Diagnostic{
+			Pointer:  joinPointer("", "components", "securitySchemes", name),
+			Rule:     "security-scheme-removed",
+			Severity: SeverityWarning,
+			Message:  "Removed the mutualTLS security scheme, which is not supported in 3.0",
+			Before:   name,
+		})
+	}
+
+	removeRequirements := func(pointer string, requirements []*base.SecurityRequirement) []*base.SecurityRequirement {
+		result := make([]*base.SecurityRequirement, 0, len(requirements))
+
+		for i, requirement := range requirements {
+			if requirement != nil && requirement.Requirements != nil &&
+				slices.ContainsFunc(removed, func(name string) bool {
+					_, ok := requirement.Requirements.Get(name)
+
+					return ok
+				}) {
+				report.Add(Diagnostic{
+					Pointer:  joinPointer(pointer, fmt.Sprint(i)),
+					Rule:     "security-requirement-removed",
+					Severity: SeverityWarning,
+					Message:  "Removed the security requirement, as it uses a scheme not supported in 3.0",
+					Before:   slices.Collect(requirement.Requirements.KeysFromOldest()),
+				})
+
+				continue
+			}
+
+			result = append(result, requirement)
+		}
+
+		// An empty list would make the document or operation public, so the field is left out instead.
+		if len(requirements) > 0 && len(result) == 0 {
+			var before []map[string][]string
+
+			for _, requirement := range requirements {
+				if requirement != nil && requirement.Requirements != nil {
+					scopes := map[string][]string{}
+
+					for name, required := range requirement.Requirements.FromOldest() {
+						scopes[name] = append([]string{}, required...)
+					}
+
+					before = append(before, scopes)
+				}
+			}
+
+			report.Add(Diagnostic{
+				Pointer:  pointer,
+				Rule:     "security-requirements-removed",
+				Severity: SeverityError,
+				Message:  removedSecurityMessage(pointer),
+				Before:   before,
+			})
+
+			return nil
+		}
+
+		return result
+	}
+
+	model.Model.Security = removeRequirements("/security", model.Model.Security)
+
+	if model.Model.Paths == nil || model.Model.Paths.PathItems == nil {
+		return
+	}
+
+	for path, pathItem := range model.Model.Paths.PathItems.FromOldest() {
+		for method, operation := range pathItem.GetOperations().FromOldest() {
+			if len(operation.Security) > 0 {
+				operation.Security = removeRequirements(
+					joinPointer("", "paths", path, method, "security"),
+					operation.Security,
+				)
+			}
+		}
+	}
+}
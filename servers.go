@@ -0,0 +1,350 @@
+package openapispecconverter
+
+import (
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// swaggerSchemes are the schemes Swagger documents can use.
+var swaggerSchemes = []string{"http", "https", "ws", "wss"}
+
+// swaggerServerURLs returns a server URL for every scheme with the host and basePath of a Swagger document.
+//
+// A relative URL for the basePath is returned if there is no host, as URLs with schemes need a host.
+func swaggerServerURLs(schemes []string, host string, basePath string) []string {
+	if len(host) == 0 {
+		if len(basePath) == 0 {
+			return nil
+		}
+
+		return []string{basePath}
+	}
+
+	if len(schemes) == 0 {
+		schemes = []string{"https"}
+	}
+
+	if len(basePath) == 0 {
+		basePath = "/"
+	}
+
+	urls := make([]string, 0, len(schemes))
+
+	for _, scheme := range schemes {
+		urls = append(urls, (&url.URL{Scheme: scheme, Host: host, Path: basePath}).String())
+	}
+
+	return urls
+}
+
+// convertSwaggerServers sets servers for Swagger documents with a basePath and no host,
+// and operations with their own schemes, which kin-openapi doesn't do.
+func convertSwaggerServers(swaggerDoc *openapi2.T, doc *openapi3.T, report *Report) {
+	if len(doc.Servers) == 0 && len(swaggerDoc.Host) == 0 && len(swaggerDoc.BasePath) > 0 {
+		doc.AddServer(&openapi3.Server{URL: swaggerDoc.BasePath})
+
+		report.Add(Diagnostic{
+			Pointer:  "/basePath",
+			Rule:     "base-path-to-servers",
+			Severity: SeverityInfo,
+			Message:  "Replaced basePath with a relative server URL, as the document has no host",
+			Before:   map[string]any{"basePath": swaggerDoc.BasePath},
+			After:    map[string]any{"servers": []map[string]any{{"url": swaggerDoc.BasePath}}},
+		})
+	}
+
+	for _, path := range slices.Sorted(maps.Keys(swaggerDoc.Paths)) {
+		swaggerPathItem := swaggerDoc.Paths[path]
+		pathItem := doc.Paths.Value(path)
+
+		if swaggerPathItem == nil || pathItem == nil {
+			continue
+		}
+
+		operations := pathItem.Operations()
+		swaggerOperations := swaggerPathItem.Operations()
+
+		for _, method := range slices.Sorted(maps.Keys(swaggerOperations)) {
+			swaggerOperation := swaggerOperations[method]
+			operation := operations[method]
+
+			if operation == nil || len(swaggerOperation.Schemes) == 0 ||
+				slices.Equal(swaggerOperation.Schemes, swaggerDoc.Schemes) {
+				continue
+			}
+
+			pointer := joinPointer("", "paths", path, strings.ToLower(method), "schemes")
+
+			if len(swaggerDoc.Host) == 0 {
+				report.Add(Diagnostic{
+					Pointer:  pointer,
+					Rule:     "schemes-to-servers",
+					Severity: SeverityWarning,
+					Message:  "Removed schemes, as server URLs with a scheme need a host",
+					Before:   swaggerOperation.Schemes,
+				})
+
+				continue
+			}
+
+			urls := swaggerServerURLs(swaggerOperation.Schemes, swaggerDoc.Host, swaggerDoc.BasePath)
+			operation.Servers = &openapi3.Servers{}
+
+			for _, serverURL := range urls {
+				*operation.Servers = append(*operation.Servers, &openapi3.Server{URL: serverURL})
+			}
+
+			report.Add(Diagnostic{
+				Pointer:  pointer,
+				Rule:     "schemes-to-servers",
+				Severity: SeverityInfo,
+				Message:  "Replaced the schemes of the operation with servers",
+				Before:   swaggerOperation.Schemes,
+				After:    urls,
+			})
+		}
+	}
+}
+
+// serverLocation is the scheme, host, and basePath of a server URL.
+type serverLocation struct {
+	url      string
+	scheme   string
+	host     string
+	basePath string
+}
+
+// sameHost returns true if two locations have the same host and basePath.
+func (location serverLocation) sameHost(other serverLocation) bool {
+	return location.host == other.host &&
+		strings.TrimSuffix(location.basePath, "/") == strings.TrimSuffix(other.basePath, "/")
+}
+
+// expandServerURL replaces the variables in a server URL with their default values.
+func expandServerURL(pointer string, server *openapi3.Server, report *Report) string {
+	if len(server.Variables) == 0 {
+		return server.URL
+	}
+
+	severity := SeverityInfo
+	message := "Replaced server variables with their default values"
+	before := map[string]any{}
+
+	for _, name := range slices.Sorted(maps.Keys(server.Variables)) {
+		variable := server.Variables[name]
+
+		if variable == nil {
+			continue
+		}
+
+		before[name] = variable.Default
+
+		if len(variable.Enum) > 1 {
+			severity = SeverityWarning
+			message = "Replaced server variables with their default values, and removed their other values"
+			before[name] = variable.Enum
+		}
+	}
+
+	expandedURL := serverVariablePattern.ReplaceAllStringFunc(server.URL, func(match string) string {
+		if variable := server.Variables[match[1:len(match)-1]]; variable != nil {
+			return variable.Default
+		}
+
+		return match
+	})
+
+	report.Add(Diagnostic{
+		Pointer:  joinPointer(pointer, "variables"),
+		Rule:     "server-variables-expanded",
+		Severity: severity,
+		Message:  message,
+		Before:   map[string]any{"url": server.URL, "variables": before},
+		After:    map[string]any{"url": expandedURL},
+	})
+
+	return expandedURL
+}
+
+// serverLocations returns the locations of servers that can be represented in Swagger,
+// and the URLs of servers that can't.
+func serverLocations(pointer string, servers openapi3.Servers, report *Report) ([]serverLocation, []string) {
+	var locations []serverLocation
+	var invalid []string
+
+	for i, server := range servers {
+		if server == nil {
+			continue
+		}
+
+		serverURL := expandServerURL(joinPointer(pointer, fmt.Sprint(i)), server, report)
+		parsedURL, err := url.Parse(serverURL)
+
+		if err != nil || serverVariablePattern.MatchString(serverURL) ||
+			(len(parsedURL.Scheme) > 0 && !slices.Contains(swaggerSchemes, parsedURL.Scheme)) {
+			invalid = append(invalid, server.URL)
+
+			continue
+		}
+
+		locations = append(locations, serverLocation{
+			url:      server.URL,
+			scheme:   parsedURL.Scheme,
+			host:     parsedURL.Host,
+			basePath: parsedURL.Path,
+		})
+	}
+
+	return locations, invalid
+}
+
+// locationSchemes returns the schemes of servers with the same host and basePath as a location,
+// and the URLs of servers with a different host or basePath.
+func locationSchemes(location serverLocation, locations []serverLocation) ([]string, []string) {
+	var schemes []string
+	var other []string
+
+	for _, otherLocation := range locations {
+		if !otherLocation.sameHost(location) {
+			other = append(other, otherLocation.url)
+		} else if len(otherLocation.scheme) > 0 && !slices.Contains(schemes, otherLocation.scheme) {
+			schemes = append(schemes, otherLocation.scheme)
+		}
+	}
+
+	return schemes, other
+}
+
+// convertOperationServersToSwagger sets the schemes of operations with their own servers,
+// when the servers have the host and basePath of the document.
+func convertOperationServersToSwagger(
+	pointer string,
+	servers openapi3.Servers,
+	location serverLocation,
+	swaggerDoc *openapi2.T,
+	swaggerOperations []*openapi2.Operation,
+	report *Report,
+) {
+	locations, removed := serverLocations(pointer, servers, report)
+	schemes, other := locationSchemes(location, locations)
+	removed = append(removed, other...)
+
+	if len(removed) > 0 {
+		report.Add(Diagnostic{
+			Pointer:  pointer,
+			Rule:     "servers-removed",
+			Severity: SeverityWarning,
+			Message:  "Removed servers, as Swagger operations can only change the schemes of the host and basePath",
+			Before:   removed,
+		})
+	}
+
+	if len(schemes) == 0 || slices.Equal(schemes, swaggerDoc.Schemes) {
+		return
+	}
+
+	for _, swaggerOperation := range swaggerOperations {
+		swaggerOperation.Schemes = schemes
+	}
+
+	report.Add(Diagnostic{
+		Pointer:  pointer,
+		Rule:     "servers-to-schemes",
+		Severity: SeverityInfo,
+		Message:  "Replaced servers with the schemes of the operation",
+		After:    map[string]any{"schemes": schemes},
+	})
+}
+
+// convertServersToSwagger sets the host, basePath, and schemes of a Swagger document from its servers,
+// and the schemes of operations with their own servers.
+//
+// kin-openapi only uses servers with no variables, and only http and https schemes.
+func convertServersToSwagger(doc *openapi3.T, swaggerDoc *openapi2.T, report *Report) {
+	locations, removed := serverLocations("/servers", doc.Servers, report)
+	var location serverLocation
+
+	swaggerDoc.Host = ""
+	swaggerDoc.BasePath = ""
+	swaggerDoc.Schemes = nil
+
+	if len(locations) > 0 {
+		location = locations[0]
+
+		var other []string
+
+		swaggerDoc.Host = location.host
+		swaggerDoc.BasePath = location.basePath
+		swaggerDoc.Schemes, other = locationSchemes(location, locations)
+		removed = append(removed, other...)
+	}
+
+	if len(removed) > 0 {
+		report.Add(Diagnostic{
+			Pointer:  "/servers",
+			Rule:     "servers-reduced",
+			Severity: SeverityWarning,
+			Message:  "Only servers with the host and basePath of the first server are used for host, basePath, and schemes",
+			Before:   removed,
+		})
+	}
+
+	if doc.Paths == nil {
+		return
+	}
+
+	paths := doc.Paths.Map()
+
+	for _, path := range slices.Sorted(maps.Keys(paths)) {
+		pathItem := paths[path]
+		swaggerPathItem := swaggerDoc.Paths[path]
+
+		if pathItem == nil || swaggerPathItem == nil {
+			continue
+		}
+
+		operations := pathItem.Operations()
+		swaggerOperations := swaggerPathItem.Operations()
+		var pathOperations []*openapi2.Operation
+
+		for _, method := range slices.Sorted(maps.Keys(operations)) {
+			operation := operations[method]
+			swaggerOperation := swaggerOperations[method]
+
+			if swaggerOperation == nil {
+				continue
+			}
+
+			if operation.Servers != nil && len(*operation.Servers) > 0 {
+				convertOperationServersToSwagger(
+					joinPointer("", "paths", path, strings.ToLower(method), "servers"),
+					*operation.Servers,
+					location,
+					swaggerDoc,
+					[]*openapi2.Operation{swaggerOperation},
+					report,
+				)
+			} else {
+				pathOperations = append(pathOperations, swaggerOperation)
+			}
+		}
+
+		// Servers for a path apply to every operation without its own servers.
+		if len(pathItem.Servers) > 0 && len(pathOperations) > 0 {
+			convertOperationServersToSwagger(
+				joinPointer("", "paths", path, "servers"),
+				pathItem.Servers,
+				location,
+				swaggerDoc,
+				pathOperations,
+				report,
+			)
+		}
+	}
+}
@@ -0,0 +1,586 @@
+package openapispecconverter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi2conv"
+	"github.com/getkin/kin-openapi/openapi3"
+	ghodssYaml "github.com/ghodss/yaml"
+	"github.com/pb33f/libopenapi"
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"gopkg.in/yaml.v3"
+)
+
+func fixSwaggerOperationUploadFormat(operation *openapi2.Operation) {
+	if operation != nil && slices.Contains(operation.Consumes, "application/octet-stream") {
+		for _, param := range operation.Parameters {
+			if param.In == "body" && param.Schema == nil {
+				param.Schema = &openapi2.SchemaRef{
+					Value: &openapi2.Schema{
+						Type:   &openapi3.Types{"string"},
+						Format: "binary",
+					},
+				}
+			}
+		}
+	}
+}
+
+func fixSwaggerDocUploadFormats(kinSwaggerDoc *openapi2.T) {
+	for _, path := range kinSwaggerDoc.Paths {
+		// HEAD, GET, DELETE we don't check here.
+		// All other operations we try to fix.
+		fixSwaggerOperationUploadFormat(path.Post)
+		fixSwaggerOperationUploadFormat(path.Options)
+		fixSwaggerOperationUploadFormat(path.Patch)
+		fixSwaggerOperationUploadFormat(path.Put)
+	}
+}
+
+func convertSwaggerToOpenAPI30(data []byte, report *Report) ([]byte, error) {
+	var kinSwaggerDoc openapi2.T
+
+	dataFormat := DetectFormat(data)
+
+	// kin-openapi cannot unmarshal YAML correctly, so we have to first convert input to JSON.
+	if dataFormat != JSON {
+		var err error
+		data, err = ghodssYaml.YAMLToJSON(data)
+
+		if err != nil {
+			return nil, fmt.Errorf("Error converting Swagger YAML to JSON: %w", err)
+		}
+	}
+
+	if err := UnmarshalSwagger(data, &kinSwaggerDoc); err != nil {
+		return nil, fmt.Errorf("Error loading Swagger data: %w", err)
+	}
+
+	// kin-openapi fails to convert body and formData parameters of path items.
+	moveSwaggerPathBodyParameters(&kinSwaggerDoc, report)
+
+	if kinOpenAPIDoc, err := openapi2conv.ToV3(&kinSwaggerDoc); err == nil {
+		// kin-openapi doesn't convert collectionFormat to style and explode.
+		convertSwaggerCollectionFormats(&kinSwaggerDoc, kinOpenAPIDoc, report)
+		// kin-openapi doesn't convert response examples either.
+		convertSwaggerExamples(&kinSwaggerDoc, kinOpenAPIDoc, report)
+		// kin-openapi doesn't create servers for operation schemes, or a basePath with no host.
+		convertSwaggerServers(&kinSwaggerDoc, kinOpenAPIDoc, report)
+		// kin-openapi loses the media type of formData parameters with no consumes, and body parameters with no schema.
+		convertSwaggerFormData(&kinSwaggerDoc, kinOpenAPIDoc, report)
+		// kin-openapi only converts x-nullable in schemas, and drops other schema extensions.
+		convertSwaggerNullable(&kinSwaggerDoc, kinOpenAPIDoc, report)
+		// kin-openapi converts security definitions one at a time, and knows nothing of our extensions for them.
+		convertSwaggerSecuritySchemes(&kinSwaggerDoc, kinOpenAPIDoc, report)
+		// This must run after the extensions of definitions are copied.
+		convertSwaggerDiscriminators(&kinSwaggerDoc, kinOpenAPIDoc, report)
+
+		return kinOpenAPIDoc.MarshalJSON()
+	} else {
+		return nil, fmt.Errorf("Error converting Swagger to 3.0 %w", err)
+	}
+}
+
+// swaggerReferencePrefixes maps the start of references to 3.0 components to the Swagger locations they become.
+var swaggerReferencePrefixes = [][2]string{
+	{"#/components/schemas/", "#/definitions/"},
+	{"#/components/parameters/", "#/parameters/"},
+	{"#/components/responses/", "#/responses/"},
+}
+
+// findValue finds a value decoded from JSON or YAML with a JSON pointer.
+func findValue(value any, pointer string) (any, bool) {
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+
+		switch parent := value.(type) {
+		case map[string]any:
+			var ok bool
+
+			if value, ok = parent[token]; !ok {
+				return nil, false
+			}
+		case []any:
+			index, err := strconv.Atoi(token)
+
+			if err != nil || index < 0 || index >= len(parent) {
+				return nil, false
+			}
+
+			value = parent[index]
+		default:
+			return nil, false
+		}
+	}
+
+	return value, true
+}
+
+// swaggerReferenceReplacer replaces references to 3.0 components in extensions, which kin-openapi doesn't update.
+type swaggerReferenceReplacer struct {
+	// openAPIDoc is the 3.0 document, for inlining components Swagger doesn't have.
+	openAPIDoc any
+	// inlining lists the references being inlined, so recursive components stop.
+	inlining []string
+	report   *Report
+}
+
+// replace replaces references in every extension in a value, and returns the new value.
+//
+// References to schemas, parameters, and responses point to their Swagger locations,
+// and other components, such as x-querystringParameters, which are removed in Swagger, are inlined.
+func (replacer *swaggerReferenceReplacer) replace(value any, pointer string, inExtension bool) any {
+	switch value := value.(type) {
+	case map[string]any:
+		if ref, ok := value["$ref"].(string); ok && inExtension && strings.HasPrefix(ref, "#/components/") {
+			for _, prefixes := range swaggerReferencePrefixes {
+				if name, ok := strings.CutPrefix(ref, prefixes[0]); ok {
+					value["$ref"] = prefixes[1] + name
+
+					return value
+				}
+			}
+
+			if target, ok := findValue(replacer.openAPIDoc, strings.TrimPrefix(ref, "#")); ok && !slices.Contains(replacer.inlining, ref) {
+				replacer.report.Add(Diagnostic{
+					Pointer:  pointer,
+					Rule:     "reference-inlined",
+					Severity: SeverityInfo,
+					Message:  "Replaced the $ref with the component, as Swagger has no " + strings.Split(ref, "/")[2],
+					Before:   ref,
+				})
+
+				// The component may be inlined several times, so it must be copied.
+				var inlined any
+				data, _ := json.Marshal(target)
+				json.Unmarshal(data, &inlined)
+
+				replacer.inlining = append(replacer.inlining, ref)
+				inlined = replacer.replace(inlined, pointer, true)
+				replacer.inlining = replacer.inlining[:len(replacer.inlining)-1]
+
+				return inlined
+			}
+
+			replacer.report.Add(Diagnostic{
+				Pointer:  pointer,
+				Rule:     "reference-unresolved",
+				Severity: SeverityWarning,
+				Message:  "Kept a $ref to a component that is recursive or missing, as it can't be inlined",
+				Before:   ref,
+			})
+		}
+
+		for key, child := range value {
+			value[key] = replacer.replace(child, joinPointer(pointer, key), inExtension || strings.HasPrefix(key, "x-"))
+		}
+	case []any:
+		for i, child := range value {
+			value[i] = replacer.replace(child, joinPointer(pointer, strconv.Itoa(i)), inExtension)
+		}
+	}
+
+	return value
+}
+
+// report30FeaturesMissingFromSwagger reports parts of a 3.0 document that cannot be represented in Swagger.
+func report30FeaturesMissingFromSwagger(
+	model *libopenapi.DocumentModel[v3.Document],
+	report *Report,
+) {
+	if model.Model.Paths != nil && model.Model.Paths.PathItems != nil {
+		for path, pathItem := range model.Model.Paths.PathItems.FromOldest() {
+			for method, operation := range pathItem.GetOperations().FromOldest() {
+				operationPointer := joinPointer("", "paths", path, method)
+
+				if operation.Callbacks != nil && operation.Callbacks.Len() > 0 {
+					report.Add(Diagnostic{
+						Pointer:  joinPointer(operationPointer, "callbacks"),
+						Rule:     "callbacks-removed",
+						Severity: SeverityWarning,
+						Message:  "Removed callbacks, which are not supported in Swagger",
+						Before:   slices.Collect(operation.Callbacks.KeysFromOldest()),
+					})
+				}
+
+				if operation.Responses != nil && operation.Responses.Codes != nil {
+					for status, response := range operation.Responses.Codes.FromOldest() {
+						if response.Links != nil && response.Links.Len() > 0 {
+							report.Add(Diagnostic{
+								Pointer:  joinPointer(operationPointer, "responses", status, "links"),
+								Rule:     "links-removed",
+								Severity: SeverityWarning,
+								Message:  "Removed links, which are not supported in Swagger",
+								Before:   slices.Collect(response.Links.KeysFromOldest()),
+							})
+						}
+					}
+				}
+			}
+		}
+	}
+
+	if model.Model.Extensions != nil {
+		if webhooks, ok := model.Model.Extensions.Get(webhooksExtension); ok {
+			report.Add(Diagnostic{
+				Pointer:  "/" + webhooksExtension,
+				Rule:     "webhooks-removed",
+				Severity: SeverityWarning,
+				Message:  "Removed x-webhooks, which are not supported in Swagger",
+				Before:   mappingKeys(webhooks),
+			})
+		}
+	}
+
+	if model.Model.Components != nil {
+		if model.Model.Components.Callbacks != nil && model.Model.Components.Callbacks.Len() > 0 {
+			report.Add(Diagnostic{
+				Pointer:  "/components/callbacks",
+				Rule:     "callbacks-removed",
+				Severity: SeverityWarning,
+				Message:  "Removed callbacks, which are not supported in Swagger",
+				Before:   slices.Collect(model.Model.Components.Callbacks.KeysFromOldest()),
+			})
+		}
+
+		// kin-openapi drops extensions of components, as Swagger has no components.
+		if model.Model.Components.Extensions != nil {
+			for name := range model.Model.Components.Extensions.KeysFromOldest() {
+				report.Add(Diagnostic{
+					Pointer:  joinPointer("/components", name),
+					Rule:     "components-extension-removed",
+					Severity: SeverityWarning,
+					Message:  "Removed " + name + " from components, as Swagger has no components",
+				})
+			}
+		}
+
+		if model.Model.Components.Links != nil && model.Model.Components.Links.Len() > 0 {
+			report.Add(Diagnostic{
+				Pointer:  "/components/links",
+				Rule:     "links-removed",
+				Severity: SeverityWarning,
+				Message:  "Removed links, which are not supported in Swagger",
+				Before:   slices.Collect(model.Model.Components.Links.KeysFromOldest()),
+			})
+		}
+	}
+}
+
+// report30CompositionMissingFromSwagger reports oneOf, anyOf, and not in a 3.0 schema,
+// which kin-openapi drops, as Swagger only has allOf.
+//
+// oneOf in component schemas with a discriminator is replaced with allOf inheritance, and reported there.
+func report30CompositionMissingFromSwagger(schema *base.Schema, pointer string, report *Report) {
+	name, isComponent := strings.CutPrefix(pointer, "/components/schemas/")
+
+	if len(schema.OneOf) > 0 && (schema.Discriminator == nil || !isComponent || strings.Contains(name, "/")) {
+		report.Add(Diagnostic{
+			Pointer:  joinPointer(pointer, "oneOf"),
+			Rule:     "one-of-removed",
+			Severity: SeverityWarning,
+			Message:  "Removed oneOf, which is not supported in Swagger",
+		})
+	}
+
+	if len(schema.AnyOf) > 0 {
+		report.Add(Diagnostic{
+			Pointer:  joinPointer(pointer, "anyOf"),
+			Rule:     "any-of-removed",
+			Severity: SeverityWarning,
+			Message:  "Removed anyOf, which is not supported in Swagger",
+		})
+	}
+
+	if schema.Not != nil {
+		report.Add(Diagnostic{
+			Pointer:  joinPointer(pointer, "not"),
+			Rule:     "not-removed",
+			Severity: SeverityWarning,
+			Message:  "Removed not, which is not supported in Swagger",
+		})
+	}
+}
+
+// removeCookieParametersFromSwagger removes cookie parameters, and references to them,
+// which kin-openapi keeps, though Swagger has no cookie parameters.
+func removeCookieParametersFromSwagger(doc *openapi3.T, swaggerDoc *openapi2.T, report *Report) {
+	removedRefs := map[string]bool{}
+
+	if doc.Components != nil {
+		for _, name := range slices.Sorted(maps.Keys(doc.Components.Parameters)) {
+			parameterRef := doc.Components.Parameters[name]
+
+			if parameterRef != nil && parameterRef.Ref == "" && parameterRef.Value != nil && parameterRef.Value.In == "cookie" {
+				delete(swaggerDoc.Parameters, name)
+				removedRefs["#/parameters/"+name] = true
+
+				report.Add(Diagnostic{
+					Pointer:  joinPointer("", "components", "parameters", name),
+					Rule:     "cookie-parameter-removed",
+					Severity: SeverityWarning,
+					Message:  "Removed the cookie parameter, as Swagger does not support cookie parameters",
+					Before:   parameterRef.Value.Name,
+				})
+			}
+		}
+
+		if len(swaggerDoc.Parameters) == 0 {
+			swaggerDoc.Parameters = nil
+		}
+	}
+
+	if doc.Paths == nil {
+		return
+	}
+
+	removeParameters := func(pointer string, parameters openapi3.Parameters, swaggerParameters openapi2.Parameters) openapi2.Parameters {
+		for i, parameterRef := range parameters {
+			if parameterRef != nil && parameterRef.Value != nil && parameterRef.Value.In == "cookie" {
+				report.Add(Diagnostic{
+					Pointer:  joinPointer(pointer, "parameters", fmt.Sprint(i)),
+					Rule:     "cookie-parameter-removed",
+					Severity: SeverityWarning,
+					Message:  "Removed the cookie parameter, as Swagger does not support cookie parameters",
+					Before:   parameterRef.Value.Name,
+				})
+			}
+		}
+
+		swaggerParameters = slices.DeleteFunc(swaggerParameters, func(parameter *openapi2.Parameter) bool {
+			return parameter != nil && (parameter.In == "cookie" || removedRefs[parameter.Ref])
+		})
+
+		if len(swaggerParameters) == 0 {
+			return nil
+		}
+
+		return swaggerParameters
+	}
+
+	paths := doc.Paths.Map()
+
+	for _, path := range slices.Sorted(maps.Keys(paths)) {
+		pathItem := paths[path]
+		swaggerPathItem := swaggerDoc.Paths[path]
+
+		if pathItem == nil || swaggerPathItem == nil {
+			continue
+		}
+
+		pathPointer := joinPointer("", "paths", path)
+		swaggerPathItem.Parameters = removeParameters(pathPointer, pathItem.Parameters, swaggerPathItem.Parameters)
+
+		operations := pathItem.Operations()
+		swaggerOperations := swaggerPathItem.Operations()
+
+		for _, method := range slices.Sorted(maps.Keys(operations)) {
+			if swaggerOperation := swaggerOperations[method]; swaggerOperation != nil {
+				swaggerOperation.Parameters = removeParameters(
+					joinPointer(pathPointer, strings.ToLower(method)),
+					operations[method].Parameters,
+					swaggerOperation.Parameters,
+				)
+			}
+		}
+	}
+}
+
+// setSwaggerParameterType sets the type of a Swagger parameter with no type to string,
+// as Swagger requires a type for parameters other than body parameters.
+//
+// kin-openapi leaves out the type for parameters with content, or with a schema that has no type.
+func setSwaggerParameterType(
+	pointer string,
+	parameter *openapi3.Parameter,
+	swaggerParameter *openapi2.Parameter,
+	report *Report,
+) {
+	if swaggerParameter.In == "body" || swaggerParameter.Type != nil {
+		return
+	}
+
+	swaggerParameter.Type = &openapi3.Types{"string"}
+	message := "Set the type of the parameter to string, as Swagger parameters need a type"
+
+	if parameter != nil && len(parameter.Content) > 0 {
+		message = "Replaced the content of the parameter with a string, as Swagger parameters have no content"
+	}
+
+	report.Add(Diagnostic{
+		Pointer:  pointer,
+		Rule:     "parameter-type-added",
+		Severity: SeverityWarning,
+		Message:  message,
+		After:    map[string]any{"type": "string"},
+	})
+}
+
+// setSwaggerParameterTypes sets the type of every Swagger parameter kin-openapi left with no type.
+func setSwaggerParameterTypes(doc *openapi3.T, swaggerDoc *openapi2.T, report *Report) {
+	for _, name := range slices.Sorted(maps.Keys(swaggerDoc.Parameters)) {
+		var parameter *openapi3.Parameter
+
+		if doc.Components != nil && doc.Components.Parameters[name] != nil {
+			parameter = doc.Components.Parameters[name].Value
+		}
+
+		if swaggerParameter := swaggerDoc.Parameters[name]; swaggerParameter != nil && swaggerParameter.Ref == "" {
+			setSwaggerParameterType(joinPointer("", "components", "parameters", name), parameter, swaggerParameter, report)
+		}
+	}
+
+	if doc.Paths == nil {
+		return
+	}
+
+	setTypes := func(pointer string, parameters openapi3.Parameters, swaggerParameters openapi2.Parameters) {
+		for i, parameterRef := range parameters {
+			if parameterRef == nil || parameterRef.Ref != "" || parameterRef.Value == nil {
+				continue
+			}
+
+			parameter := parameterRef.Value
+
+			if swaggerParameter := findSwaggerParameter(swaggerParameters, parameter.In, parameter.Name); swaggerParameter != nil {
+				setSwaggerParameterType(joinPointer(pointer, "parameters", fmt.Sprint(i)), parameter, swaggerParameter, report)
+			}
+		}
+	}
+
+	paths := doc.Paths.Map()
+
+	for _, path := range slices.Sorted(maps.Keys(paths)) {
+		pathItem := paths[path]
+		swaggerPathItem := swaggerDoc.Paths[path]
+
+		if pathItem == nil || swaggerPathItem == nil {
+			continue
+		}
+
+		pathPointer := joinPointer("", "paths", path)
+		setTypes(pathPointer, pathItem.Parameters, swaggerPathItem.Parameters)
+
+		operations := pathItem.Operations()
+		swaggerOperations := swaggerPathItem.Operations()
+
+		for _, method := range slices.Sorted(maps.Keys(operations)) {
+			if swaggerOperation := swaggerOperations[method]; swaggerOperation != nil {
+				setTypes(joinPointer(pathPointer, strings.ToLower(method)), operations[method].Parameters, swaggerOperation.Parameters)
+			}
+		}
+	}
+}
+
+func convertOpenAPI30ToSwagger(data []byte, report *Report) ([]byte, error) {
+	doc, err := libopenapi.NewDocument(data)
+
+	if err != nil {
+		return nil, fmt.Errorf("Error loading document: %w", err)
+	}
+
+	// Build the document in libopenapi so we can modify the document
+	// to correct issues not handled by kin-openapi.
+	model, errs := doc.BuildV3Model()
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("Errors loading document: %w", errors.Join(errs...))
+	}
+
+	report30FeaturesMissingFromSwagger(model, report)
+
+	if model.Model.Extensions != nil {
+		model.Model.Extensions.Delete(webhooksExtension)
+	}
+
+	updateAllSchema(model, func(schema *base.Schema, pointer string) {
+		// We must make every property that is both required and also readonly
+		// only be readonly, or they will break Swagger validation.
+		make30RequiredAndReadonlyPropertiesOnlyReadonly(schema, pointer, report)
+		// kin-openapi drops oneOf, anyOf, and not without saying so.
+		report30CompositionMissingFromSwagger(schema, pointer, report)
+	})
+
+	updateAllParameters(model, func(parameter *v3.Parameter, pointer string) {
+		// kin-openapi only sets x-nullable for schemas, not parameters.
+		convert30ParameterNullable(parameter, pointer, report)
+	})
+
+	data, err = renderDocument(doc, model)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var kinSwaggerDoc *openapi2.T
+
+	if kinOpenAPIDoc, err := openapi3.NewLoader().LoadFromData(data); err == nil {
+		// kin-openapi crashes converting documents without components.
+		if kinOpenAPIDoc.Components == nil {
+			kinOpenAPIDoc.Components = &openapi3.Components{}
+		}
+
+		// kin-openapi fails for security schemes Swagger doesn't support, and only converts one OAuth2 flow,
+		// so security schemes are converted separately.
+		securitySchemes := kinOpenAPIDoc.Components.SecuritySchemes
+		kinOpenAPIDoc.Components.SecuritySchemes = nil
+
+		kinSwaggerDoc, err = openapi2conv.FromV3(kinOpenAPIDoc)
+
+		if err != nil {
+			return nil, fmt.Errorf("Error converting 3.0 to Swagger %w", err)
+		}
+
+		// kin-openapi ignores servers with variables, and servers for paths and operations.
+		convertServersToSwagger(kinOpenAPIDoc, kinSwaggerDoc, report)
+		// kin-openapi keeps cookie parameters, and leaves parameters with content with no type.
+		removeCookieParametersFromSwagger(kinOpenAPIDoc, kinSwaggerDoc, report)
+		setSwaggerParameterTypes(kinOpenAPIDoc, kinSwaggerDoc, report)
+		convertSecuritySchemesToSwagger(securitySchemes, kinSwaggerDoc, report)
+		// kin-openapi loses details of form request bodies and binary schemas.
+		// This must run first, as formData parameters are created again.
+		convertFormBodiesToSwagger(kinOpenAPIDoc, kinSwaggerDoc, report)
+		// kin-openapi doesn't convert style and explode to collectionFormat.
+		convertStylesToCollectionFormats(kinOpenAPIDoc, kinSwaggerDoc, report)
+		// kin-openapi doesn't convert media type examples either.
+		convertMediaTypeExamplesToSwagger(kinOpenAPIDoc, kinSwaggerDoc, report)
+		// kin-openapi drops discriminators, and oneOf, which Swagger doesn't have.
+		convertDiscriminatorsToSwagger(kinOpenAPIDoc, kinSwaggerDoc, report)
+	} else {
+		return nil, fmt.Errorf("Error Load 3.0 for converting to Swagger %w", err)
+	}
+
+	// The kin-openapi Swagger converter doesn't add {schema: {type: "string", format: "binary"}}
+	// when creating upload specs for binary content. We need to add it back in again.
+	fixSwaggerDocUploadFormats(kinSwaggerDoc)
+
+	openAPIData := data
+	data, err = kinSwaggerDoc.MarshalJSON()
+
+	// Only extensions can have references to components left in them.
+	if err != nil || !bytes.Contains(data, []byte("#/components/")) {
+		return data, err
+	}
+
+	var swaggerDoc any
+	replacer := swaggerReferenceReplacer{report: report}
+
+	if err := json.Unmarshal(data, &swaggerDoc); err != nil {
+		return nil, fmt.Errorf("Error loading Swagger document: %w", err)
+	}
+
+	if err := yaml.Unmarshal(openAPIData, &replacer.openAPIDoc); err != nil {
+		return nil, fmt.Errorf("Error loading 3.0 document: %w", err)
+	}
+
+	return json.Marshal(replacer.replace(swaggerDoc, "", false))
+}
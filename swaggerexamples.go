@@ -0,0 +1,245 @@
+package openapispecconverter
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// mediaTypeExampleValue returns the `example` of a media type, or the first of its `examples` by name.
+func mediaTypeExampleValue(mediaType *openapi3.MediaType) (any, bool) {
+	if mediaType.Example != nil {
+		return mediaType.Example, true
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(mediaType.Examples)) {
+		if example := mediaType.Examples[name]; example != nil && example.Value != nil && example.Value.Value != nil {
+			return example.Value.Value, true
+		}
+	}
+
+	return nil, false
+}
+
+// convertResponseExamplesToSwagger sets the Swagger `examples` of a response, keyed by media type.
+func convertResponseExamplesToSwagger(
+	pointer string,
+	responseRef *openapi3.ResponseRef,
+	swaggerResponse *openapi2.Response,
+	report *Report,
+) {
+	if responseRef == nil || responseRef.Ref != "" || responseRef.Value == nil || swaggerResponse == nil {
+		return
+	}
+
+	for _, contentType := range slices.Sorted(maps.Keys(responseRef.Value.Content)) {
+		mediaType := responseRef.Value.Content[contentType]
+
+		if mediaType == nil {
+			continue
+		}
+
+		example, ok := mediaTypeExampleValue(mediaType)
+
+		if !ok {
+			continue
+		}
+
+		if swaggerResponse.Examples == nil {
+			swaggerResponse.Examples = map[string]any{}
+		}
+
+		swaggerResponse.Examples[contentType] = example
+		severity := SeverityInfo
+		message := "Moved the example for " + contentType + " to the examples of the response"
+
+		if len(mediaType.Examples) > 1 || (mediaType.Example != nil && len(mediaType.Examples) > 0) {
+			severity = SeverityWarning
+			message = "Kept one of the examples for " + contentType + ", as Swagger has one example per media type"
+		}
+
+		report.Add(Diagnostic{
+			Pointer:  joinPointer(pointer, "content", contentType),
+			Rule:     "example-to-response-examples",
+			Severity: severity,
+			Message:  message,
+			After:    map[string]any{"examples": map[string]any{contentType: example}},
+		})
+	}
+}
+
+// reportRequestBodyExamplesRemoved reports request body examples, which Swagger body parameters can't have.
+func reportRequestBodyExamplesRemoved(pointer string, requestBodyRef *openapi3.RequestBodyRef, report *Report) {
+	if requestBodyRef == nil || requestBodyRef.Ref != "" || requestBodyRef.Value == nil {
+		return
+	}
+
+	for _, contentType := range slices.Sorted(maps.Keys(requestBodyRef.Value.Content)) {
+		if mediaType := requestBodyRef.Value.Content[contentType]; mediaType != nil {
+			if example, ok := mediaTypeExampleValue(mediaType); ok {
+				report.Add(Diagnostic{
+					Pointer:  joinPointer(pointer, "content", contentType),
+					Rule:     "example-removed",
+					Severity: SeverityWarning,
+					Message:  "Removed the example for " + contentType + ", as Swagger body parameters have no examples",
+					Before:   example,
+				})
+			}
+		}
+	}
+}
+
+// convertMediaTypeExamplesToSwagger moves the examples of response media types to Swagger response `examples`,
+// which kin-openapi doesn't do.
+func convertMediaTypeExamplesToSwagger(doc *openapi3.T, swaggerDoc *openapi2.T, report *Report) {
+	if doc.Components != nil {
+		for _, name := range slices.Sorted(maps.Keys(doc.Components.Responses)) {
+			convertResponseExamplesToSwagger(
+				joinPointer("", "components", "responses", name),
+				doc.Components.Responses[name],
+				swaggerDoc.Responses[name],
+				report,
+			)
+		}
+
+		for _, name := range slices.Sorted(maps.Keys(doc.Components.RequestBodies)) {
+			reportRequestBodyExamplesRemoved(
+				joinPointer("", "components", "requestBodies", name),
+				doc.Components.RequestBodies[name],
+				report,
+			)
+		}
+	}
+
+	if doc.Paths == nil {
+		return
+	}
+
+	for _, path := range doc.Paths.InMatchingOrder() {
+		pathItem := doc.Paths.Value(path)
+		swaggerPathItem := swaggerDoc.Paths[path]
+
+		if pathItem == nil || swaggerPathItem == nil {
+			continue
+		}
+
+		operations := pathItem.Operations()
+		swaggerOperations := swaggerPathItem.Operations()
+
+		for _, method := range slices.Sorted(maps.Keys(operations)) {
+			operation := operations[method]
+			swaggerOperation := swaggerOperations[method]
+
+			if operation == nil || swaggerOperation == nil {
+				continue
+			}
+
+			operationPointer := joinPointer("", "paths", path, strings.ToLower(method))
+			reportRequestBodyExamplesRemoved(joinPointer(operationPointer, "requestBody"), operation.RequestBody, report)
+
+			if operation.Responses == nil {
+				continue
+			}
+
+			for _, code := range slices.Sorted(maps.Keys(operation.Responses.Map())) {
+				convertResponseExamplesToSwagger(
+					joinPointer(operationPointer, "responses", code),
+					operation.Responses.Value(code),
+					swaggerOperation.Responses[code],
+					report,
+				)
+			}
+		}
+	}
+}
+
+// convertSwaggerResponseExamples sets the `example` of response media types from Swagger response `examples`.
+func convertSwaggerResponseExamples(
+	pointer string,
+	swaggerResponse *openapi2.Response,
+	responseRef *openapi3.ResponseRef,
+	report *Report,
+) {
+	if swaggerResponse == nil || swaggerResponse.Ref != "" || len(swaggerResponse.Examples) == 0 ||
+		responseRef == nil || responseRef.Ref != "" || responseRef.Value == nil {
+		return
+	}
+
+	response := responseRef.Value
+
+	for _, contentType := range slices.Sorted(maps.Keys(swaggerResponse.Examples)) {
+		mediaType := response.Content.Get(contentType)
+
+		if mediaType == nil {
+			// Examples can be given for media types the operation doesn't list in `produces`.
+			mediaType = &openapi3.MediaType{}
+
+			if swaggerResponse.Schema != nil && len(response.Content) > 0 {
+				mediaType.Schema = response.Content[slices.Sorted(maps.Keys(response.Content))[0]].Schema
+			}
+
+			if response.Content == nil {
+				response.Content = openapi3.Content{}
+			}
+
+			response.Content[contentType] = mediaType
+		}
+
+		mediaType.Example = swaggerResponse.Examples[contentType]
+
+		report.Add(Diagnostic{
+			Pointer:  joinPointer(pointer, "examples", contentType),
+			Rule:     "response-examples-to-example",
+			Severity: SeverityInfo,
+			Message:  "Moved the example for " + contentType + " to the example of the media type",
+			Before:   map[string]any{"examples": map[string]any{contentType: mediaType.Example}},
+		})
+	}
+}
+
+// convertSwaggerExamples moves Swagger response `examples` to the example of each media type,
+// which kin-openapi doesn't do.
+func convertSwaggerExamples(swaggerDoc *openapi2.T, doc *openapi3.T, report *Report) {
+	if doc.Components != nil {
+		for _, name := range slices.Sorted(maps.Keys(swaggerDoc.Responses)) {
+			convertSwaggerResponseExamples(
+				joinPointer("", "responses", name),
+				swaggerDoc.Responses[name],
+				doc.Components.Responses[name],
+				report,
+			)
+		}
+	}
+
+	for _, path := range slices.Sorted(maps.Keys(swaggerDoc.Paths)) {
+		swaggerPathItem := swaggerDoc.Paths[path]
+		pathItem := doc.Paths.Value(path)
+
+		if swaggerPathItem == nil || pathItem == nil {
+			continue
+		}
+
+		operations := pathItem.Operations()
+		swaggerOperations := swaggerPathItem.Operations()
+
+		for _, method := range slices.Sorted(maps.Keys(swaggerOperations)) {
+			operation := operations[method]
+
+			if operation == nil || operation.Responses == nil {
+				continue
+			}
+
+			for _, code := range slices.Sorted(maps.Keys(swaggerOperations[method].Responses)) {
+				convertSwaggerResponseExamples(
+					joinPointer("", "paths", path, strings.ToLower(method), "responses", code),
+					swaggerOperations[method].Responses[code],
+					operation.Responses.Value(code),
+					report,
+				)
+			}
+		}
+	}
+}
@@ -0,0 +1,392 @@
+package openapispecconverter
+
+import (
+	"reflect"
+	"strconv"
+
+	"github.com/pb33f/libopenapi"
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/libopenapi/datamodel/low"
+	"github.com/pb33f/libopenapi/orderedmap"
+)
+
+// isReference checks if a high-level object was loaded from a `$ref`.
+//
+// libopenapi renders such objects back out as references, so we skip them
+// and update them where they are defined instead.
+func isReference(object interface{ GoLowUntyped() any }) bool {
+	lowObject, ok := object.GoLowUntyped().(low.IsReferenced)
+
+	if !ok || reflect.ValueOf(lowObject).IsNil() {
+		return false
+	}
+
+	return lowObject.IsReference()
+}
+
+// schemaUpdater walks every location in a document where a schema can be defined.
+type schemaUpdater struct {
+	callback func(schema *base.Schema, pointer string)
+	// encodingCallback is called for every encoding in request and response content, if set.
+	encodingCallback func(encoding *v3.Encoding, pointer string)
+	// referenceCallback is called for every schema loaded from a `$ref`, if set.
+	referenceCallback func(schemaProxy *base.SchemaProxy, pointer string)
+	// contentCallback is called for the content of every request body and response, if set.
+	contentCallback func(content *orderedmap.Map[string, *v3.MediaType], pointer string, request bool)
+	// parameterCallback is called for every parameter, if set.
+	parameterCallback func(parameter *v3.Parameter, pointer string)
+	// visited holds every object we have seen, so shared or cyclic objects are only updated once.
+	visited map[any]bool
+}
+
+// visit marks an object as visited, and returns true if it was not visited before.
+func (updater *schemaUpdater) visit(object any) bool {
+	if updater.visited[object] {
+		return false
+	}
+
+	updater.visited[object] = true
+
+	return true
+}
+
+func (updater *schemaUpdater) updateSchemaMap(
+	schemaMap *orderedmap.Map[string, *base.SchemaProxy],
+	pointer string,
+) {
+	if schemaMap != nil {
+		for name, schemaProxy := range schemaMap.FromOldest() {
+			updater.updateSchema(schemaProxy, joinPointer(pointer, name))
+		}
+	}
+}
+
+func (updater *schemaUpdater) updateSchemaList(schemaList []*base.SchemaProxy, pointer string) {
+	for i, schemaProxy := range schemaList {
+		updater.updateSchema(schemaProxy, joinPointer(pointer, strconv.Itoa(i)))
+	}
+}
+
+func (updater *schemaUpdater) updateSchema(schemaProxy *base.SchemaProxy, pointer string) {
+	if schemaProxy == nil {
+		return
+	}
+
+	// Skip references, which are updated where they are defined.
+	if schemaProxy.IsReference() {
+		if updater.referenceCallback != nil {
+			updater.referenceCallback(schemaProxy, pointer)
+		}
+
+		return
+	}
+
+	schema := schemaProxy.Schema()
+
+	if schema == nil || !updater.visit(schema) {
+		return
+	}
+
+	// Handle schemas in properties.
+	updater.updateSchemaMap(schema.Properties, joinPointer(pointer, "properties"))
+	updater.updateSchemaMap(schema.PatternProperties, joinPointer(pointer, "patternProperties"))
+	updater.updateSchemaMap(schema.DependentSchemas, joinPointer(pointer, "dependentSchemas"))
+
+	if schema.AdditionalProperties != nil && schema.AdditionalProperties.IsA() {
+		updater.updateSchema(schema.AdditionalProperties.A, joinPointer(pointer, "additionalProperties"))
+	}
+
+	if schema.UnevaluatedProperties != nil && schema.UnevaluatedProperties.IsA() {
+		updater.updateSchema(schema.UnevaluatedProperties.A, joinPointer(pointer, "unevaluatedProperties"))
+	}
+
+	updater.updateSchema(schema.PropertyNames, joinPointer(pointer, "propertyNames"))
+
+	// Handle items if the schema is an array.
+	if schema.Items != nil && schema.Items.IsA() {
+		updater.updateSchema(schema.Items.A, joinPointer(pointer, "items"))
+	}
+
+	updater.updateSchemaList(schema.PrefixItems, joinPointer(pointer, "prefixItems"))
+	updater.updateSchema(schema.Contains, joinPointer(pointer, "contains"))
+	updater.updateSchema(schema.UnevaluatedItems, joinPointer(pointer, "unevaluatedItems"))
+
+	// Process composite schemas: allOf, oneOf, anyOf, and not.
+	updater.updateSchemaList(schema.AllOf, joinPointer(pointer, "allOf"))
+	updater.updateSchemaList(schema.OneOf, joinPointer(pointer, "oneOf"))
+	updater.updateSchemaList(schema.AnyOf, joinPointer(pointer, "anyOf"))
+	updater.updateSchema(schema.Not, joinPointer(pointer, "not"))
+
+	// Process conditional schemas.
+	updater.updateSchema(schema.If, joinPointer(pointer, "if"))
+	updater.updateSchema(schema.Then, joinPointer(pointer, "then"))
+	updater.updateSchema(schema.Else, joinPointer(pointer, "else"))
+
+	// Modify this schema last, so our changes to schema are final.
+	updater.callback(schema, pointer)
+}
+
+func (updater *schemaUpdater) updateContent(content *orderedmap.Map[string, *v3.MediaType], pointer string) {
+	if content == nil {
+		return
+	}
+
+	for contentType, mediaType := range content.FromOldest() {
+		if mediaType == nil {
+			continue
+		}
+
+		mediaTypePointer := joinPointer(pointer, contentType)
+
+		updater.updateSchema(mediaType.Schema, joinPointer(mediaTypePointer, "schema"))
+
+		if mediaType.Encoding != nil {
+			for property, encoding := range mediaType.Encoding.FromOldest() {
+				if encoding != nil {
+					encodingPointer := joinPointer(mediaTypePointer, "encoding", property)
+
+					updater.updateHeaders(encoding.Headers, joinPointer(encodingPointer, "headers"))
+
+					if updater.encodingCallback != nil {
+						updater.encodingCallback(encoding, encodingPointer)
+					}
+				}
+			}
+		}
+	}
+}
+
+func (updater *schemaUpdater) updateHeaders(headers *orderedmap.Map[string, *v3.Header], pointer string) {
+	if headers == nil {
+		return
+	}
+
+	for name, header := range headers.FromOldest() {
+		if header == nil || isReference(header) || !updater.visit(header) {
+			continue
+		}
+
+		headerPointer := joinPointer(pointer, name)
+
+		updater.updateSchema(header.Schema, joinPointer(headerPointer, "schema"))
+		updater.updateContent(header.Content, joinPointer(headerPointer, "content"))
+	}
+}
+
+func (updater *schemaUpdater) updateParameter(parameter *v3.Parameter, pointer string) {
+	if parameter == nil || isReference(parameter) || !updater.visit(parameter) {
+		return
+	}
+
+	updater.updateSchema(parameter.Schema, joinPointer(pointer, "schema"))
+	updater.updateContent(parameter.Content, joinPointer(pointer, "content"))
+
+	if updater.parameterCallback != nil {
+		updater.parameterCallback(parameter, pointer)
+	}
+}
+
+func (updater *schemaUpdater) updateParameterList(parameters []*v3.Parameter, pointer string) {
+	for i, parameter := range parameters {
+		updater.updateParameter(parameter, joinPointer(pointer, strconv.Itoa(i)))
+	}
+}
+
+func (updater *schemaUpdater) updateRequestBody(requestBody *v3.RequestBody, pointer string) {
+	if requestBody == nil || isReference(requestBody) || !updater.visit(requestBody) {
+		return
+	}
+
+	updater.updateContent(requestBody.Content, joinPointer(pointer, "content"))
+
+	if updater.contentCallback != nil && requestBody.Content != nil {
+		updater.contentCallback(requestBody.Content, joinPointer(pointer, "content"), true)
+	}
+}
+
+func (updater *schemaUpdater) updateResponse(response *v3.Response, pointer string) {
+	if response == nil || isReference(response) || !updater.visit(response) {
+		return
+	}
+
+	updater.updateHeaders(response.Headers, joinPointer(pointer, "headers"))
+	updater.updateContent(response.Content, joinPointer(pointer, "content"))
+
+	if updater.contentCallback != nil && response.Content != nil {
+		updater.contentCallback(response.Content, joinPointer(pointer, "content"), false)
+	}
+}
+
+func (updater *schemaUpdater) updateCallbacks(callbacks *orderedmap.Map[string, *v3.Callback], pointer string) {
+	if callbacks == nil {
+		return
+	}
+
+	for name, callback := range callbacks.FromOldest() {
+		if callback == nil || isReference(callback) || !updater.visit(callback) {
+			continue
+		}
+
+		if callback.Expression != nil {
+			for expression, pathItem := range callback.Expression.FromOldest() {
+				updater.updatePathItem(pathItem, joinPointer(pointer, name, expression))
+			}
+		}
+	}
+}
+
+func (updater *schemaUpdater) updateOperation(operation *v3.Operation, pointer string) {
+	if operation == nil || !updater.visit(operation) {
+		return
+	}
+
+	updater.updateParameterList(operation.Parameters, joinPointer(pointer, "parameters"))
+	updater.updateRequestBody(operation.RequestBody, joinPointer(pointer, "requestBody"))
+
+	if operation.Responses != nil {
+		if operation.Responses.Codes != nil {
+			for status, response := range operation.Responses.Codes.FromOldest() {
+				updater.updateResponse(response, joinPointer(pointer, "responses", status))
+			}
+		}
+
+		updater.updateResponse(operation.Responses.Default, joinPointer(pointer, "responses", "default"))
+	}
+
+	updater.updateCallbacks(operation.Callbacks, joinPointer(pointer, "callbacks"))
+}
+
+func (updater *schemaUpdater) updatePathItem(pathItem *v3.PathItem, pointer string) {
+	if pathItem == nil || isReference(pathItem) || !updater.visit(pathItem) {
+		return
+	}
+
+	updater.updateParameterList(pathItem.Parameters, joinPointer(pointer, "parameters"))
+
+	for method, operation := range pathItem.GetOperations().FromOldest() {
+		updater.updateOperation(operation, joinPointer(pointer, method))
+	}
+}
+
+func (updater *schemaUpdater) updatePathItemMap(pathItems *orderedmap.Map[string, *v3.PathItem], pointer string) {
+	if pathItems != nil {
+		for path, pathItem := range pathItems.FromOldest() {
+			updater.updatePathItem(pathItem, joinPointer(pointer, path))
+		}
+	}
+}
+
+func (updater *schemaUpdater) updateComponents(components *v3.Components) {
+	if components == nil {
+		return
+	}
+
+	updater.updateSchemaMap(components.Schemas, "/components/schemas")
+
+	if components.Parameters != nil {
+		for name, parameter := range components.Parameters.FromOldest() {
+			updater.updateParameter(parameter, joinPointer("/components/parameters", name))
+		}
+	}
+
+	if components.RequestBodies != nil {
+		for name, requestBody := range components.RequestBodies.FromOldest() {
+			updater.updateRequestBody(requestBody, joinPointer("/components/requestBodies", name))
+		}
+	}
+
+	if components.Responses != nil {
+		for name, response := range components.Responses.FromOldest() {
+			updater.updateResponse(response, joinPointer("/components/responses", name))
+		}
+	}
+
+	updater.updateHeaders(components.Headers, "/components/headers")
+	updater.updateCallbacks(components.Callbacks, "/components/callbacks")
+	updater.updatePathItemMap(components.PathItems, "/components/pathItems")
+}
+
+func (updater *schemaUpdater) updateDocument(model *libopenapi.DocumentModel[v3.Document]) {
+	updater.updateComponents(model.Model.Components)
+
+	if model.Model.Paths != nil {
+		updater.updatePathItemMap(model.Model.Paths.PathItems, "/paths")
+	}
+
+	updater.updatePathItemMap(model.Model.Webhooks, "/webhooks")
+}
+
+// updateAllSchema Finds schema anywhere they are used in spec and updates them using the `callback`
+//
+// The callback is given a JSON pointer to the location of each schema.
+// Nested schema are updated before the schema containing them.
+func updateAllSchema(
+	model *libopenapi.DocumentModel[v3.Document],
+	callback func(schema *base.Schema, pointer string),
+) {
+	updater := schemaUpdater{
+		callback: callback,
+		visited:  map[any]bool{},
+	}
+
+	updater.updateDocument(model)
+}
+
+// updateAllEncodings finds every encoding for request or response content and updates them using the `callback`
+func updateAllEncodings(
+	model *libopenapi.DocumentModel[v3.Document],
+	callback func(encoding *v3.Encoding, pointer string),
+) {
+	updater := schemaUpdater{
+		callback:         func(schema *base.Schema, pointer string) {},
+		encodingCallback: callback,
+		visited:          map[any]bool{},
+	}
+
+	updater.updateDocument(model)
+}
+
+// updateAllSchemaReferences finds every schema `$ref` in spec and updates them using the `callback`
+func updateAllSchemaReferences(
+	model *libopenapi.DocumentModel[v3.Document],
+	callback func(schemaProxy *base.SchemaProxy, pointer string),
+) {
+	updater := schemaUpdater{
+		callback:          func(schema *base.Schema, pointer string) {},
+		referenceCallback: callback,
+		visited:           map[any]bool{},
+	}
+
+	updater.updateDocument(model)
+}
+
+// updateAllContent finds the content of every request body and response in spec and updates them using the `callback`
+//
+// `request` is true for the content of request bodies, and false for the content of responses.
+func updateAllContent(
+	model *libopenapi.DocumentModel[v3.Document],
+	callback func(content *orderedmap.Map[string, *v3.MediaType], pointer string, request bool),
+) {
+	updater := schemaUpdater{
+		callback:        func(schema *base.Schema, pointer string) {},
+		contentCallback: callback,
+		visited:         map[any]bool{},
+	}
+
+	updater.updateDocument(model)
+}
+
+// updateAllParameters finds every parameter in spec and updates them using the `callback`
+func updateAllParameters(
+	model *libopenapi.DocumentModel[v3.Document],
+	callback func(parameter *v3.Parameter, pointer string),
+) {
+	updater := schemaUpdater{
+		callback:          func(schema *base.Schema, pointer string) {},
+		parameterCallback: callback,
+		visited:           map[any]bool{},
+	}
+
+	updater.updateDocument(model)
+}